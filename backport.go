@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/backport"
+	"github.com/sverweij/vcodeowners/internal/checksum"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+	"github.com/sverweij/vcodeowners/internal/textdiff"
+)
+
+// teamsFile is a virtual teams file backport can edit memberships in
+type teamsFile struct {
+	name     string
+	content  string
+	teamMap  teams.Map
+	settings teams.Settings
+}
+
+// readTeamsFiles reads the files in the virtual teams format among the team
+// map locations. LDIF files come from elsewhere, so backport doesn't edit
+// them.
+func readTeamsFiles(locations []string) ([]teamsFile, error) {
+	var files []teamsFile
+	for _, location := range locations {
+		if location == "" {
+			continue
+		}
+		fileNames, fileNamesError := getTeamMapFileNames(location)
+		if fileNamesError != nil {
+			return nil, fileNamesError
+		}
+		slices.Sort(fileNames)
+		for _, fileName := range fileNames {
+			if strings.EqualFold(filepath.Ext(fileName), ".ldif") {
+				continue
+			}
+			teamMapBytes, teamMapReadError := os.ReadFile(fileName)
+			if teamMapReadError != nil {
+				return nil, teamMapReadError
+			}
+			teamMap, teamSettings, teamMapParseError := teams.ParseWithSettings(string(teamMapBytes))
+			if teamMapParseError != nil {
+				return nil, fmt.Errorf("%s: %w", fileName, teamMapParseError)
+			}
+			files = append(files, teamsFile{name: fileName, content: string(teamMapBytes), teamMap: teamMap, settings: teamSettings})
+		}
+	}
+	return files, nil
+}
+
+// editMemberships applies the membership edits to the virtual teams files
+// that define the teams, and returns the updated content of the files that
+// changed. A member leaves the team in each file that lists them, and joins
+// it in the first file that defines it.
+func editMemberships(files []teamsFile, edits []backport.MembershipEdit) (map[string]string, error) {
+	fileEdits := make([][]backport.MembershipEdit, len(files))
+	for _, edit := range edits {
+		var definingFiles []int
+		for i, file := range files {
+			if _, found := file.teamMap[edit.Team]; found {
+				definingFiles = append(definingFiles, i)
+			}
+		}
+		if len(definingFiles) == 0 {
+			return nil, fmt.Errorf("team '%s' isn't in a virtual teams file backport can edit (e.g. it comes from an LDIF file or a GitHub teams export)", edit.Team)
+		}
+		if !edit.Remove {
+			definingFiles = definingFiles[:1]
+		}
+		for _, i := range definingFiles {
+			fileEdits[i] = append(fileEdits[i], edit)
+		}
+	}
+
+	updated := map[string]string{}
+	for i, file := range files {
+		if len(fileEdits[i]) == 0 {
+			continue
+		}
+		editedMap, editedSettings := backport.ApplyMembershipEdits(file.teamMap, file.settings, fileEdits[i])
+		formatted, formatError := teams.FormatLike(file.content, editedMap, editedSettings)
+		if formatError != nil {
+			return nil, formatError
+		}
+		if formatted != file.content {
+			updated[file.name] = formatted
+		}
+	}
+	return updated, nil
+}
+
+// backportCli compares the CODEOWNERS on disk with the one vcodeowners would
+// generate, and proposes changes to the VIRTUAL-CODEOWNERS.txt and the
+// virtual teams files that would generate the edited one. It takes the same
+// options as generating does, so it compares with the same CODEOWNERS.
+func backportCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("backport", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners backport [options]\n\n")
+		fmt.Fprint(output, "Turns edits made by hand to a generated CODEOWNERS into changes to the VIRTUAL-CODEOWNERS.txt and virtual-teams.json.\n")
+		fmt.Fprint(output, "Pass the options you generate CODEOWNERS with\n\n")
+		flags.PrintDefaults()
+	}
+	options := addOptions(flags)
+	apply := flags.Bool("apply", false, "Write the proposed changes to the VIRTUAL-CODEOWNERS.txt and the virtual teams files")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+
+	virtualCodeOwnersBytes, virtualCodeOwnersReadError := os.ReadFile(*options.virtualCodeOwners)
+	if virtualCodeOwnersReadError != nil {
+		return "", virtualCodeOwnersReadError
+	}
+	virtualCodeOwners := string(virtualCodeOwnersBytes)
+	if codeowners.HasIncludes(virtualCodeOwners) {
+		return "", fmt.Errorf("'%s' has #!include directives; backport can't tell which file to back-port edits to yet", *options.virtualCodeOwners)
+	}
+	if *options.distributedOwners != "" {
+		return "", fmt.Errorf("backport can't tell which owners file to back-port edits to with --distributedOwners yet")
+	}
+	if len(*options.backstageCatalog) > 0 {
+		return "", fmt.Errorf("backport can't tell the rules from the Backstage catalog apart from those in '%s' yet", *options.virtualCodeOwners)
+	}
+
+	codeOwnersBytes, codeOwnersReadError := os.ReadFile(*options.codeOwners)
+	if codeOwnersReadError != nil {
+		return "", codeOwnersReadError
+	}
+	editedLines, editedSyntaxErrors := codeowners.Parse(strings.TrimPrefix(checksum.Strip(string(codeOwnersBytes)), string(codeOwnersHeaderComment)))
+	if _, syntaxError := handleAnomalies(editedSyntaxErrors, "Syntax errors found in the input:", "fail"); syntaxError != nil {
+		return "", syntaxError
+	}
+
+	generated, generateError := generate(options)
+	if generateError != nil {
+		return "", generateError
+	}
+	result, backportError := backport.Backport(generated.virtualLines, generated.teamMap, generated.applyOptions, editedLines)
+	if backportError != nil {
+		return "", backportError
+	}
+	returnMessage := generated.message
+	if len(result.Changes) == 0 {
+		return returnMessage + "No edits to back-port\n", nil
+	}
+
+	teamsFiles, teamsFilesError := readTeamsFiles(*options.teamMap)
+	if teamsFilesError != nil {
+		return "", teamsFilesError
+	}
+	updatedTeamsFiles, editError := editMemberships(teamsFiles, result.MembershipEdits)
+	if editError != nil {
+		return "", editError
+	}
+
+	returnMessage = returnMessage + "Proposed changes:\n"
+	for _, change := range result.Changes {
+		returnMessage = returnMessage + "  " + change + "\n"
+	}
+	returnMessage = returnMessage + "\n" + textdiff.Unified(*options.virtualCodeOwners, *options.virtualCodeOwners, virtualCodeOwners, result.VirtualCodeOwners)
+	for _, file := range teamsFiles {
+		if updated, found := updatedTeamsFiles[file.name]; found {
+			returnMessage = returnMessage + textdiff.Unified(file.name, file.name, file.content, updated)
+		}
+	}
+
+	if !*apply {
+		return returnMessage + "\nRun 'vcodeowners backport --apply' with the same options to write them\n", nil
+	}
+	if result.VirtualCodeOwners != virtualCodeOwners {
+		if writeError := os.WriteFile(*options.virtualCodeOwners, []byte(result.VirtualCodeOwners), 0644); writeError != nil {
+			return "", writeError
+		}
+		returnMessage = returnMessage + fmt.Sprintf("\nWrote '%s'", *options.virtualCodeOwners)
+	}
+	for _, file := range teamsFiles {
+		if updated, found := updatedTeamsFiles[file.name]; found {
+			if writeError := os.WriteFile(file.name, []byte(updated), 0644); writeError != nil {
+				return "", writeError
+			}
+			returnMessage = returnMessage + fmt.Sprintf("\nWrote '%s'", file.name)
+		}
+	}
+	return returnMessage + "\nRun vcodeowners to regenerate the CODEOWNERS from them\n", nil
+}
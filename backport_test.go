@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackportCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	coFileName := filepath.Join(directory, "CODEOWNERS")
+	vcoFileName := filepath.Join(directory, "VIRTUAL-CODEOWNERS.txt")
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	os.WriteFile(vcoFileName, []byte("/sales/ @ch/sales\n/docs/ @ch/sales @davy\n"), 0644)
+	os.WriteFile(teamsFileName, []byte("{\n  \"ch/sales\": [\n    \"jane\",\n    \"karl\"\n  ]\n}\n"), 0644)
+	arguments := []string{
+		"--codeOwners", coFileName,
+		"--virtualCodeOwners", vcoFileName,
+		"--virtualTeams", teamsFileName,
+	}
+
+	t.Run("nothing to back-port when the CODEOWNERS wasn't edited", func(t *testing.T) {
+		os.WriteFile(coFileName, []byte(string(codeOwnersHeaderComment)+"/sales/ @jane @karl\n/docs/ @jane @karl @davy\n\n"), 0644)
+		message, error := backportCli(arguments, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal("No edits to back-port\n", message)
+	})
+
+	t.Run("proposes changes and doesn't write anything", func(t *testing.T) {
+		os.WriteFile(coFileName, []byte(string(codeOwnersHeaderComment)+"/sales/ @jane\n/docs/ @jane\n"), 0644)
+		message, error := backportCli(arguments, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Proposed changes:\n"+
+				"  team 'ch/sales': remove karl\n"+
+				"  line 2: remove @davy\n\n"+
+				"--- "+vcoFileName+"\n+++ "+vcoFileName+"\n@@ -1,2 +1,2 @@\n /sales/ @ch/sales\n-/docs/ @ch/sales @davy\n+/docs/ @ch/sales\n"+
+				"--- "+teamsFileName+"\n+++ "+teamsFileName+"\n@@ -1,6 +1,5 @@\n {\n   \"ch/sales\": [\n-    \"jane\",\n-    \"karl\"\n+    \"jane\"\n   ]\n }\n"+
+				"\nRun 'vcodeowners backport --apply' with the same options to write them\n",
+			message,
+		)
+		virtualTeams, _ := os.ReadFile(teamsFileName)
+		assert.Contains(string(virtualTeams), "karl")
+	})
+
+	t.Run("writes the back-ported sources with --apply", func(t *testing.T) {
+		_, error := backportCli(append(arguments, "--apply"), io.Discard)
+
+		assert.Nil(error)
+		virtualCodeOwners, _ := os.ReadFile(vcoFileName)
+		assert.Equal("/sales/ @ch/sales\n/docs/ @ch/sales\n", string(virtualCodeOwners))
+		virtualTeams, _ := os.ReadFile(teamsFileName)
+		assert.Equal("{\n  \"ch/sales\": [\n    \"jane\"\n  ]\n}\n", string(virtualTeams))
+
+		message, _ := backportCli(arguments, io.Discard)
+		assert.Equal("No edits to back-port\n", message)
+	})
+
+	t.Run("compares with the CODEOWNERS generated with the same options", func(t *testing.T) {
+		os.WriteFile(vcoFileName, []byte("/sales/ @ch/sales\n/docs/ @ch/docs\n"), 0644)
+		os.WriteFile(teamsFileName, []byte("{\n  \"ch/sales\": [\n    \"jane\",\n    { \"name\": \"karl\", \"until\": \"2026-06-30\" }\n  ]\n}\n"), 0644)
+		docsTeamsFileName := filepath.Join(directory, "docs-teams.json")
+		os.WriteFile(docsTeamsFileName, []byte("{\n  \"ch/docs\": [\n    \"davy\",\n    \"mary\"\n  ]\n}\n"), 0644)
+		os.WriteFile(coFileName, []byte(string(codeOwnersHeaderComment)+"/sales/ @jane @karl\n/docs/ @davy\n"), 0644)
+
+		message, error := backportCli(append(arguments, "--virtualTeams", docsTeamsFileName, "--asOf", "2026-06-01", "--apply"), io.Discard)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Proposed changes:\n"+
+				"  team 'ch/docs': remove mary\n\n"+
+				"--- "+docsTeamsFileName+"\n+++ "+docsTeamsFileName+"\n@@ -1,6 +1,5 @@\n {\n   \"ch/docs\": [\n-    \"davy\",\n-    \"mary\"\n+    \"davy\"\n   ]\n }\n"+
+				"\nWrote '"+docsTeamsFileName+"'\nRun vcodeowners to regenerate the CODEOWNERS from them\n",
+			message,
+		)
+	})
+
+	t.Run("keeps the layout of the virtual teams file", func(t *testing.T) {
+		os.WriteFile(vcoFileName, []byte("/sales/ @ch/sales\n/ux/ @ch/ux\n"), 0644)
+		os.WriteFile(teamsFileName, []byte("{\n    \"ch/ux\": [\"davy\"],\n    \"ch/sales\": [\"jane\", \"karl\"]\n}\n"), 0644)
+		os.WriteFile(coFileName, []byte(string(codeOwnersHeaderComment)+"/sales/ @jane\n/ux/ @davy\n"), 0644)
+
+		_, error := backportCli(append(arguments, "--apply"), io.Discard)
+
+		assert.Nil(error)
+		virtualTeams, _ := os.ReadFile(teamsFileName)
+		assert.Equal("{\n    \"ch/ux\": [\"davy\"],\n    \"ch/sales\": [\"jane\"]\n}\n", string(virtualTeams))
+	})
+
+	t.Run("error: owners files in subdirectories", func(t *testing.T) {
+		_, error := backportCli(append(arguments, "--distributedOwners", "VIRTUAL-CODEOWNERS.txt"), io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("backport can't tell which owners file to back-port edits to with --distributedOwners yet", error.Error())
+	})
+
+	t.Run("error: unknown virtual teams file", func(t *testing.T) {
+		_, error := backportCli([]string{"--codeOwners", coFileName, "--virtualCodeOwners", vcoFileName, "--virtualTeams", "delete_me_not_there.json"}, io.Discard)
+
+		assert.NotNil(error)
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/backstage"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// isCatalogFileName returns true for the names Backstage catalog files
+// usually have
+func isCatalogFileName(fileName string) bool {
+	return fileName == "catalog-info.yaml" || fileName == "catalog-info.yml"
+}
+
+// getCatalogFileNames returns the Backstage catalog files a location refers
+// to: all catalog-info.yaml files in and below a directory, the files
+// matching a glob and otherwise just the location itself.
+func getCatalogFileNames(location string) ([]string, error) {
+	if fileInfo, statError := os.Stat(location); statError == nil && fileInfo.IsDir() {
+		var fileNames []string
+		walkError := filepath.WalkDir(location, func(path string, entry fs.DirEntry, walkError error) error {
+			if walkError != nil {
+				return walkError
+			}
+			if entry.IsDir() && path != location && (entry.Name() == ".git" || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && isCatalogFileName(entry.Name()) {
+				fileNames = append(fileNames, path)
+			}
+			return nil
+		})
+		return fileNames, walkError
+	}
+	if strings.ContainsAny(location, "*?[") {
+		fileNames, globError := filepath.Glob(location)
+		if globError != nil {
+			return nil, globError
+		}
+		if len(fileNames) == 0 {
+			return nil, fmt.Errorf("no Backstage catalog files match '%s'", location)
+		}
+		return fileNames, nil
+	}
+	return []string{location}, nil
+}
+
+// readCatalog reads the entities in the Backstage catalog files at the
+// locations
+func readCatalog(locations []string) (backstage.Catalog, error) {
+	var entities []backstage.Entity
+
+	for _, location := range locations {
+		fileNames, fileNamesError := getCatalogFileNames(location)
+		if fileNamesError != nil {
+			return backstage.Catalog{}, fileNamesError
+		}
+		slices.Sort(fileNames)
+
+		for _, fileName := range fileNames {
+			catalogBytes, catalogReadError := os.ReadFile(fileName)
+			if catalogReadError != nil {
+				return backstage.Catalog{}, catalogReadError
+			}
+			fileEntities, catalogParseError := backstage.Parse(string(catalogBytes), filepath.ToSlash(fileName))
+			if catalogParseError != nil {
+				return backstage.Catalog{}, catalogParseError
+			}
+			entities = append(entities, fileEntities...)
+		}
+	}
+	return backstage.NewCatalog(entities), nil
+}
+
+// getRepositoryName returns the name of the repository (see
+// backstage.RepositoryName) its 'origin' remote points to, or "" when
+// there's no such remote
+func getRepositoryName() string {
+	remoteURL, remoteError := runGit("remote", "get-url", "origin")
+	if remoteError != nil {
+		return ""
+	}
+	return backstage.RepositoryName(remoteURL)
+}
+
+// exportBackstageCli writes the virtual teams as Backstage Group entities
+func exportBackstageCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("export-backstage", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners export-backstage [options]\n\n")
+		fmt.Fprint(output, "Writes the virtual teams as Backstage Group entities\n\n")
+		flags.PrintDefaults()
+	}
+	virtualTeams := stringListFlag{values: []string{".github/virtual-teams.json"}}
+	flags.Var(&virtualTeams, "virtualTeams", "A JSON file listing teams and their members, or an LDIF export of a directory. Repeat it, or pass a directory or a glob to combine multiple files")
+	gitHubTeams := stringListFlag{}
+	flags.Var(&gitHubTeams, "gitHubTeams", "An export of the teams of a GitHub organization and their members. Its teams become virtual teams as they are. Repeat it, or pass a directory or a glob to combine multiple files")
+	teamMergeStrategy := flags.String("teamMergeStrategy", "error", "What to do with teams defined in more than one virtual teams file. error: exit, union: combine them, override: use the last one")
+	outputLocation := flags.String("output", "catalog-groups.yaml", "The file to write the Backstage Group entities to")
+	asOf := flags.String("asOf", "", "Only include team members that are active on this date (YYYY-MM-DD). Default: today")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if !mergeStrategyValid(*teamMergeStrategy) {
+		return "",
+			fmt.Errorf("invalid teamMergeStrategy option '%s'; valid options: error, union, override", *teamMergeStrategy)
+	}
+	asOfDate, asOfError := parseAsOf(*asOf)
+	if asOfError != nil {
+		return "", asOfError
+	}
+
+	teamMap, teamSettings, warnings, teamMapError := readTeamMaps(virtualTeams.values, teamSourceOptions{
+		mergeStrategy: *teamMergeStrategy,
+		ldif:          teams.LDIFOptions{HandleAttribute: "githubUsername"},
+		gitHubTeams:   gitHubTeams.values,
+	})
+	if teamMapError != nil {
+		return "", teamMapError
+	}
+	teamMap, teamSettings, membershipWarnings := teams.ActiveOn(teamMap, teamSettings, asOfDate)
+	formatted, formatError := backstage.FormatGroups(teamMap, teamSettings)
+	if formatError != nil {
+		return "", formatError
+	}
+	writeError := os.WriteFile(*outputLocation, []byte(formatted), 0644)
+	if writeError != nil {
+		return "", writeError
+	}
+	return reportWarnings(append(warnings, membershipWarnings...), "warn") + fmt.Sprintf("\nWrote '%s'\n", *outputLocation), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCatalog(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	os.MkdirAll(filepath.Join(directory, "libs", "sales"), 0755)
+	os.MkdirAll(filepath.Join(directory, "node_modules", "some-package"), 0755)
+	os.WriteFile(filepath.Join(directory, "catalog-info.yaml"), []byte("kind: Group\nmetadata:\n  name: platform\n"), 0644)
+	os.WriteFile(filepath.Join(directory, "libs", "sales", "catalog-info.yml"), []byte("kind: Group\nmetadata:\n  name: sales\n"), 0644)
+	os.WriteFile(filepath.Join(directory, "libs", "sales", "other.yaml"), []byte("kind: Group\nmetadata:\n  name: other\n"), 0644)
+	os.WriteFile(filepath.Join(directory, "node_modules", "some-package", "catalog-info.yaml"), []byte("kind: Group\nmetadata:\n  name: vendored\n"), 0644)
+
+	t.Run("reads all catalog-info files in and below a directory", func(t *testing.T) {
+		catalog, error := readCatalog([]string{directory})
+
+		assert.Nil(error)
+		assert.Len(catalog.Entities, 2)
+		assert.Equal("platform", catalog.Entities[0].Metadata.Name)
+		assert.Equal("sales", catalog.Entities[1].Metadata.Name)
+		assert.Equal(filepath.ToSlash(filepath.Join(directory, "libs", "sales", "catalog-info.yml")), catalog.Entities[1].Location)
+	})
+
+	t.Run("reads files and globs", func(t *testing.T) {
+		catalog, error := readCatalog([]string{
+			filepath.Join(directory, "catalog-info.yaml"),
+			filepath.Join(directory, "libs", "*", "other.yaml"),
+		})
+
+		assert.Nil(error)
+		assert.Len(catalog.Entities, 2)
+		assert.Equal("other", catalog.Entities[1].Metadata.Name)
+	})
+
+	t.Run("error: glob without matches", func(t *testing.T) {
+		_, error := readCatalog([]string{filepath.Join(directory, "*.yml")})
+
+		assert.NotNil(error)
+		assert.Equal("no Backstage catalog files match '"+filepath.Join(directory, "*.yml")+"'", error.Error())
+	})
+}
+
+func TestExportBackstageCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	outputFileName := filepath.Join(directory, "catalog-groups.yaml")
+	os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane-doe"]}`), 0644)
+
+	t.Run("writes the virtual teams as groups", func(t *testing.T) {
+		message, error := exportBackstageCli([]string{"--virtualTeams", teamsFileName, "--output", outputFileName}, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal("\nWrote '"+outputFileName+"'\n", message)
+		groups, _ := os.ReadFile(outputFileName)
+		assert.Contains(string(groups), "kind: Group\nmetadata:\n  name: sales\n  namespace: ch\n")
+	})
+
+	t.Run("only exports the active members, as of --asOf", func(t *testing.T) {
+		windowsFileName := filepath.Join(directory, "windowed-teams.json")
+		os.WriteFile(windowsFileName, []byte(
+			`{"ch/ux": ["davy", {"name": "john", "until": "2000-01-01"}, {"name": "mary", "from": "2999-01-01"}]}`,
+		), 0644)
+
+		message, error := exportBackstageCli([]string{"--virtualTeams", windowsFileName, "--output", outputFileName}, io.Discard)
+		assert.Nil(error)
+		assert.Contains(message, "Membership of team 'ch/ux' ended on 2000-01-01")
+		groups, _ := os.ReadFile(outputFileName)
+		assert.Contains(string(groups), "davy")
+		assert.NotContains(string(groups), "john")
+		assert.NotContains(string(groups), "mary")
+
+		_, error = exportBackstageCli([]string{"--virtualTeams", windowsFileName, "--output", outputFileName, "--asOf", "1999-12-31"}, io.Discard)
+		assert.Nil(error)
+		groups, _ = os.ReadFile(outputFileName)
+		assert.Contains(string(groups), "john")
+	})
+
+	t.Run("error: invalid teamMergeStrategy", func(t *testing.T) {
+		_, error := exportBackstageCli([]string{"--teamMergeStrategy", "coinflip"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("invalid teamMergeStrategy option 'coinflip'; valid options: error, union, override", error.Error())
+	})
+}
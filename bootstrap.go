@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/checksum"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// refuseToOverwrite returns an error when the file exists
+func refuseToOverwrite(fileName string) error {
+	if _, statError := os.Stat(fileName); statError == nil {
+		return fmt.Errorf("'%s' already exists; pass --force to overwrite it", fileName)
+	}
+	return nil
+}
+
+// initCli proposes virtual teams for the recurring owner sets in an
+// existing CODEOWNERS file and writes a VIRTUAL-CODEOWNERS.txt and virtual
+// teams file that use them. It only writes them when they regenerate to an
+// equivalent CODEOWNERS.
+func initCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners init [options]\n\n")
+		fmt.Fprint(output, "Proposes virtual teams for the owners that recur in an existing CODEOWNERS, and writes a VIRTUAL-CODEOWNERS.txt and virtual-teams.json that use them\n\n")
+		flags.PrintDefaults()
+	}
+	codeOwnersLocation := flags.String("codeOwners", ".github/CODEOWNERS", "The existing CODEOWNERS file")
+	virtualCodeOwnersLocation := flags.String("virtualCodeOwners", ".github/VIRTUAL-CODEOWNERS.txt", "Where to write the VIRTUAL-CODEOWNERS.txt")
+	virtualTeamsLocation := flags.String("virtualTeams", ".github/virtual-teams.json", "Where to write the virtual teams")
+	teamPrefix := flags.String("teamPrefix", "team/", "Goes in front of the names of the proposed teams")
+	minOccurrences := flags.Int("minOccurrences", 2, "Only propose teams for owners that occur together on at least this many lines")
+	force := flags.Bool("force", false, "Overwrite the VIRTUAL-CODEOWNERS.txt and virtual teams file when they exist")
+	dryRun := flags.Bool("dryRun", false, "Just show the proposed teams, don't write anything")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+
+	bytes, readFileError := os.ReadFile(*codeOwnersLocation)
+	if readFileError != nil {
+		return "", readFileError
+	}
+	codeOwnersLines, syntaxErrors := codeowners.Parse(strings.TrimPrefix(checksum.Strip(string(bytes)), string(codeOwnersHeaderComment)))
+	if _, syntaxError := handleAnomalies(syntaxErrors, "Syntax errors found in the input:", "fail"); syntaxError != nil {
+		return "", syntaxError
+	}
+
+	virtualCodeOwnersLines, teamMap := teams.Infer(codeOwnersLines, teams.InferOptions{
+		MinOccurrences: *minOccurrences,
+		TeamPrefix:     *teamPrefix,
+	})
+	virtualCodeOwners, formatError := virtualCodeOwnersLines.Format("")
+	if formatError != nil {
+		return "", formatError
+	}
+	virtualCodeOwners = strings.TrimRight(virtualCodeOwners, "\n") + "\n"
+	virtualTeams, teamsFormatError := teams.Format(teamMap, nil)
+	if teamsFormatError != nil {
+		return "", teamsFormatError
+	}
+
+	// regenerate from what we'd write, so what gets checked is what the
+	// next run of vcodeowners will see
+	regeneratedLines, _ := codeowners.Parse(virtualCodeOwners)
+	regeneratedTeamMap, teamMapParseError := teams.Parse(virtualTeams)
+	if teamMapParseError != nil {
+		return "", teamMapParseError
+	}
+	regeneratedCodeOwnersLines, _, applyError := teams.ApplyWithOptions(regeneratedLines, regeneratedTeamMap, teams.Options{})
+	if applyError != nil {
+		return "", applyError
+	}
+	if equivalenceError := teams.CheckEquivalent(codeOwnersLines, regeneratedCodeOwnersLines); equivalenceError != nil {
+		return "", fmt.Errorf("the proposed virtual teams don't regenerate an equivalent CODEOWNERS: %w", equivalenceError)
+	}
+
+	returnMessage := fmt.Sprintf("Proposed %d virtual team(s); rename them to taste:\n", len(teamMap))
+	returnMessage = returnMessage + teams.DiffMembers(teams.Map{}, teamMap).String()
+
+	if *dryRun {
+		return returnMessage + fmt.Sprintf(
+			"\nWrote '%s' and '%s' (dry run)\n", *virtualCodeOwnersLocation, *virtualTeamsLocation,
+		), nil
+	}
+	if !*force {
+		if overwriteError := refuseToOverwrite(*virtualCodeOwnersLocation); overwriteError != nil {
+			return "", overwriteError
+		}
+		if overwriteError := refuseToOverwrite(*virtualTeamsLocation); overwriteError != nil {
+			return "", overwriteError
+		}
+	}
+	if writeError := os.WriteFile(*virtualCodeOwnersLocation, []byte(virtualCodeOwners), 0644); writeError != nil {
+		return "", writeError
+	}
+	if writeError := os.WriteFile(*virtualTeamsLocation, []byte(virtualTeams), 0644); writeError != nil {
+		return "", writeError
+	}
+	return returnMessage + fmt.Sprintf(
+		"\nWrote '%s' and '%s'\n", *virtualCodeOwnersLocation, *virtualTeamsLocation,
+	), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	coFileName := filepath.Join(directory, "CODEOWNERS")
+	vcoFileName := filepath.Join(directory, "VIRTUAL-CODEOWNERS.txt")
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	os.WriteFile(coFileName, []byte(
+		string(codeOwnersHeaderComment)+
+			"* @jane @karl @davy\n"+
+			"libs/sales/ @karl @jane\n"+
+			"apps/sales/ @jane @karl\n",
+	), 0644)
+	arguments := []string{
+		"--codeOwners", coFileName,
+		"--virtualCodeOwners", vcoFileName,
+		"--virtualTeams", teamsFileName,
+		"--teamPrefix", "ch/",
+	}
+
+	t.Run("dry run proposes teams and doesn't write anything", func(t *testing.T) {
+		message, error := initCli(append(arguments, "--dryRun"), io.Discard)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Proposed 1 virtual team(s); rename them to taste:\nch/sales\n  + jane\n  + karl\n\n"+
+				"Wrote '"+vcoFileName+"' and '"+teamsFileName+"' (dry run)\n",
+			message,
+		)
+		_, statError := os.Stat(vcoFileName)
+		assert.NotNil(statError)
+	})
+
+	t.Run("writes files that regenerate to an equivalent CODEOWNERS", func(t *testing.T) {
+		_, error := initCli(arguments, io.Discard)
+
+		assert.Nil(error)
+		virtualCodeOwners, _ := os.ReadFile(vcoFileName)
+		assert.Equal("* @ch/sales @davy\nlibs/sales/ @ch/sales\napps/sales/ @ch/sales\n", string(virtualCodeOwners))
+		virtualTeams, _ := os.ReadFile(teamsFileName)
+		assert.Equal("{\n  \"ch/sales\": [\n    \"jane\",\n    \"karl\"\n  ]\n}\n", string(virtualTeams))
+	})
+
+	t.Run("error: refuses to overwrite existing files", func(t *testing.T) {
+		_, error := initCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'"+vcoFileName+"' already exists; pass --force to overwrite it", error.Error())
+
+		_, error = initCli(append(arguments, "--force"), io.Discard)
+		assert.Nil(error)
+	})
+
+	t.Run("error: syntax errors in the CODEOWNERS", func(t *testing.T) {
+		invalidFileName := filepath.Join(directory, "INVALID-CODEOWNERS")
+		os.WriteFile(invalidFileName, []byte("* jane\n"), 0644)
+
+		_, error := initCli([]string{"--codeOwners", invalidFileName, "--dryRun"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("Syntax errors found in the input:\n  Line    1, Invalid user 'jane': \"* jane\"\n", error.Error())
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// generateAt generates CODEOWNERS from the sources as they are in the
+// revision: a directory with (a copy of) the sources, or a git revision.
+// The sources of a git revision go into the directory.
+func generateAt(options cliOptionsType, revision string, directory string) (codeowners.CST, error) {
+	relocated, relocateError := withLocations(options, func(location string) (string, error) {
+		if fileInfo, statError := os.Stat(revision); statError == nil && fileInfo.IsDir() {
+			if filepath.IsAbs(location) {
+				return location, nil
+			}
+			return filepath.Join(revision, location), nil
+		}
+		if locationError := checkSingleFile(location); locationError != nil {
+			return "", locationError
+		}
+		content, showError := runGit("show", revision+":"+filepath.ToSlash(location))
+		if showError != nil {
+			return "", fmt.Errorf("can't read '%s' from '%s': %w", location, revision, showError)
+		}
+		return writeSource(directory, location, content)
+	})
+	if relocateError != nil {
+		return nil, relocateError
+	}
+	generated, generateError := generate(relocated)
+	if generateError != nil {
+		return nil, fmt.Errorf("%s: %w", revision, generateError)
+	}
+	return generated.lines, nil
+}
+
+// diffOwnershipCli shows who gained and who lost ownership of what between
+// two revisions of the sources
+func diffOwnershipCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("diff-ownership", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners diff-ownership [options] --from <revision> [--to <revision>]\n\n")
+		fmt.Fprint(output, "Shows who gains (+) and who loses (-) ownership of which patterns between two revisions of VIRTUAL-CODEOWNERS.txt and the virtual teams\n")
+		fmt.Fprint(output, "A revision is a git revision (e.g. main or HEAD~1) or a directory with a copy of the sources\n\n")
+		flags.PrintDefaults()
+	}
+	from := flags.String("from", "", "The revision to compare")
+	to := flags.String("to", ".", "The revision to compare it to. Default: the working tree")
+	markdown := flags.Bool("markdown", false, "Show the changes as markdown tables, e.g. for a pull request description")
+	options := addOptions(flags)
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if *from == "" {
+		flags.Usage()
+		return "", fmt.Errorf("diff-ownership needs a --from revision")
+	}
+
+	directory, tempError := os.MkdirTemp("", "vcodeowners-diff-")
+	if tempError != nil {
+		return "", tempError
+	}
+	defer os.RemoveAll(directory)
+
+	before, beforeError := generateAt(options, *from, filepath.Join(directory, "from"))
+	if beforeError != nil {
+		return "", beforeError
+	}
+	after, afterError := generateAt(options, *to, filepath.Join(directory, "to"))
+	if afterError != nil {
+		return "", afterError
+	}
+
+	changes := codeowners.DiffOwnership(before, after)
+	if *markdown {
+		return changes.Markdown(), nil
+	}
+	return changes.String(), nil
+}
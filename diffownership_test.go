@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffOwnershipCli(t *testing.T) {
+	assert := assert.New(t)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	git(t, "config", "user.name", "jane")
+	git(t, "config", "user.email", "jane@example.com")
+	os.Mkdir(".github", 0755)
+	os.WriteFile(".github/VIRTUAL-CODEOWNERS.txt", []byte("libs/sales/ @ch/sales\ndocs/ @davy\n"), 0644)
+	os.WriteFile(".github/virtual-teams.json", []byte(`{"ch/sales": ["jane", "karl"]}`), 0644)
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "sources")
+	os.WriteFile(".github/virtual-teams.json", []byte(`{"ch/sales": ["jane", "gregory"]}`), 0644)
+
+	t.Run("between a git revision and the working tree", func(t *testing.T) {
+		message, error := diffOwnershipCli([]string{"--from", "HEAD"}, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Per pattern:\nlibs/sales/\n  + @gregory\n  - @karl\n\nPer owner:\n@gregory\n  + libs/sales/\n@karl\n  - libs/sales/\n",
+			message,
+		)
+	})
+
+	t.Run("between two directories, as markdown", func(t *testing.T) {
+		otherDirectory := t.TempDir()
+		os.Mkdir(filepath.Join(otherDirectory, ".github"), 0755)
+		os.WriteFile(filepath.Join(otherDirectory, ".github/VIRTUAL-CODEOWNERS.txt"), []byte("libs/sales/ @ch/sales\ndocs/ @ch/sales\n"), 0644)
+		os.WriteFile(filepath.Join(otherDirectory, ".github/virtual-teams.json"), []byte(`{"ch/sales": ["jane", "gregory"]}`), 0644)
+
+		message, error := diffOwnershipCli([]string{"--from", ".", "--to", otherDirectory, "--markdown"}, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal(
+			"#### Ownership changes per pattern\n\n| Pattern | Gained | Lost |\n| --- | --- | --- |\n"+
+				"| `docs/` | `@gregory`, `@jane` | `@davy` |\n"+
+				"\n#### Ownership changes per owner\n\n| Owner | Gained | Lost |\n| --- | --- | --- |\n"+
+				"| `@davy` |  | `docs/` |\n| `@gregory` | `docs/` |  |\n| `@jane` | `docs/` |  |\n",
+			message,
+		)
+	})
+
+	t.Run("error: source that isn't in the revision", func(t *testing.T) {
+		_, error := diffOwnershipCli([]string{"--from", "HEAD", "--identities", "identities.json"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Contains(error.Error(), "can't read 'identities.json' from 'HEAD'")
+	})
+
+	t.Run("error: no --from", func(t *testing.T) {
+		_, error := diffOwnershipCli([]string{}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("diff-ownership needs a --from revision", error.Error())
+	})
+}
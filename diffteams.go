@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// diffTeamsCli shows the membership changes between two sets of team
+// sources, e.g. an export of the real GitHub teams and the virtual teams
+func diffTeamsCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("diff-teams", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners diff-teams [options]\n\n")
+		fmt.Fprint(output, "Shows who's in --to but not in --from (+) and the other way around (-), per team\n\n")
+		flags.PrintDefaults()
+	}
+	from := stringListFlag{}
+	to := stringListFlag{}
+	fromGitHubTeams := stringListFlag{}
+	toGitHubTeams := stringListFlag{}
+	flags.Var(&from, "from", "A virtual teams file or LDIF export to compare. Repeat it, or pass a directory or a glob to combine multiple files")
+	flags.Var(&to, "to", "A virtual teams file or LDIF export to compare it to. Repeat it, or pass a directory or a glob to combine multiple files")
+	flags.Var(&fromGitHubTeams, "fromGitHubTeams", "A GitHub teams export to compare, instead of or next to --from. Repeat it, or pass a directory or a glob to combine multiple files")
+	flags.Var(&toGitHubTeams, "toGitHubTeams", "A GitHub teams export to compare it to, instead of or next to --to. Repeat it, or pass a directory or a glob to combine multiple files")
+	teamMergeStrategy := flags.String("teamMergeStrategy", "error", "What to do with teams defined in more than one file on either side. error: exit, union: combine them, override: use the last one")
+	ldifGroups := flags.String("ldifGroups", "^cn=([^,]+)", "Regular expression for the DNs of the groups in .ldif files to use as teams (case insensitive)")
+	ldifTeamName := flags.String("ldifTeamName", "$1", "The name of teams from .ldif files, with $1, $2, ... replaced by the groups in --ldifGroups")
+	ldifHandle := flags.String("ldifHandle", "githubUsername", "The attribute of users in .ldif files that holds their handle")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if (len(from.values) == 0 && len(fromGitHubTeams.values) == 0) || (len(to.values) == 0 && len(toGitHubTeams.values) == 0) {
+		flags.Usage()
+		return "", fmt.Errorf("diff-teams needs both --from (or --fromGitHubTeams) and --to (or --toGitHubTeams)")
+	}
+	if !mergeStrategyValid(*teamMergeStrategy) {
+		return "",
+			fmt.Errorf("invalid teamMergeStrategy option '%s'; valid options: error, union, override", *teamMergeStrategy)
+	}
+	ldifOptions, ldifOptionsError := getLDIFOptions(*ldifGroups, *ldifTeamName, *ldifHandle)
+	if ldifOptionsError != nil {
+		return "", ldifOptionsError
+	}
+	fromOptions := teamSourceOptions{mergeStrategy: *teamMergeStrategy, ldif: ldifOptions, gitHubTeams: fromGitHubTeams.values}
+	toOptions := teamSourceOptions{mergeStrategy: *teamMergeStrategy, ldif: ldifOptions, gitHubTeams: toGitHubTeams.values}
+
+	fromMap, _, fromWarnings, fromError := readTeamMaps(from.values, fromOptions)
+	if fromError != nil {
+		return "", fromError
+	}
+	toMap, _, toWarnings, toError := readTeamMaps(to.values, toOptions)
+	if toError != nil {
+		return "", toError
+	}
+	return reportWarnings(append(fromWarnings, toWarnings...), "warn") + teams.DiffMembers(fromMap, toMap).String(), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffTeamsCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	gitHubTeamsFileName := filepath.Join(directory, "github-teams.json")
+	virtualTeamsFileName := filepath.Join(directory, "virtual-teams.json")
+	os.WriteFile(gitHubTeamsFileName, []byte(`{
+		"teams": [{"slug": "sales", "organization": {"login": "cloud-heroes"}}],
+		"members": {"sales": [{"login": "jane-doe"}, {"login": "karl"}]}
+	}`), 0644)
+	os.WriteFile(virtualTeamsFileName, []byte(`{"cloud-heroes/sales": ["jane-doe", "gregory"]}`), 0644)
+
+	t.Run("shows the membership changes between a GitHub teams export and virtual teams", func(t *testing.T) {
+		message, error := diffTeamsCli([]string{"--fromGitHubTeams", gitHubTeamsFileName, "--to", virtualTeamsFileName}, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal("cloud-heroes/sales\n  + gregory\n  - karl\n", message)
+	})
+
+	t.Run("error: a GitHub teams export passed with --from", func(t *testing.T) {
+		_, error := diffTeamsCli([]string{"--from", gitHubTeamsFileName, "--to", virtualTeamsFileName}, io.Discard)
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: missing --to", func(t *testing.T) {
+		_, error := diffTeamsCli([]string{"--fromGitHubTeams", gitHubTeamsFileName}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("diff-teams needs both --from (or --fromGitHubTeams) and --to (or --toGitHubTeams)", error.Error())
+	})
+
+	t.Run("error: invalid teamMergeStrategy", func(t *testing.T) {
+		_, error := diffTeamsCli([]string{"--fromGitHubTeams", gitHubTeamsFileName, "--to", virtualTeamsFileName, "--teamMergeStrategy", "coinflip"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("invalid teamMergeStrategy option 'coinflip'; valid options: error, union, override", error.Error())
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"cmp"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// getDistributedOwnersFileNames returns the owners files with the name in
+// the directory and its subdirectories (except the VIRTUAL-CODEOWNERS.txt
+// itself), parents before their children
+func getDistributedOwnersFileNames(name string, virtualCodeOwners string) ([]string, error) {
+	var fileNames []string
+	walkError := filepath.WalkDir(".", func(path string, entry fs.DirEntry, walkError error) error {
+		if walkError != nil {
+			return walkError
+		}
+		if entry.IsDir() && path != "." && (entry.Name() == ".git" || entry.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() && entry.Name() == name && filepath.Clean(path) != filepath.Clean(virtualCodeOwners) {
+			fileNames = append(fileNames, filepath.ToSlash(path))
+		}
+		return nil
+	})
+	// compare directory by directory, so parents come before their children
+	// (and 'libs/sales' before 'libs/sales-ops')
+	slices.SortFunc(fileNames, func(left string, right string) int {
+		return slices.CompareFunc(
+			strings.Split(filepath.ToSlash(filepath.Dir(left)), "/"),
+			strings.Split(filepath.ToSlash(filepath.Dir(right)), "/"),
+			cmp.Compare[string],
+		)
+	})
+	return fileNames, walkError
+}
+
+// splitAtSections splits the lines into those before the first section
+// heading and those from it on
+func splitAtSections(lines codeowners.CST) (codeowners.CST, codeowners.CST) {
+	for i, line := range lines {
+		if line.Type == "section-heading" {
+			return lines[:i], lines[i:]
+		}
+	}
+	return lines, nil
+}
+
+// readDistributedOwners reads the owners files with the name in the
+// subdirectories and returns their lines, with the patterns relative to the
+// root of the repository: first those outside of sections and then those in
+// sections. On GitLab a section runs until the next one, so the lines
+// outside of sections go before the first section of the
+// VIRTUAL-CODEOWNERS.txt and those in sections after it. The lines of each
+// file start with a comment that tells where they're from.
+func readDistributedOwners(name string, virtualCodeOwners string) (codeowners.CST, codeowners.CST, codeowners.Anomalies, error) {
+	if name == "" {
+		return nil, nil, nil, nil
+	}
+	fileNames, fileNamesError := getDistributedOwnersFileNames(name, virtualCodeOwners)
+	if fileNamesError != nil {
+		return nil, nil, nil, fileNamesError
+	}
+
+	var lines codeowners.CST
+	var sectionLines codeowners.CST
+	var anomalies codeowners.Anomalies
+	for _, fileName := range fileNames {
+		content, origins, readError := readVirtualCodeOwners(fileName)
+		if readError != nil {
+			return nil, nil, nil, readError
+		}
+		fileLines, fileAnomalies := origins.Locate(codeowners.Parse(strings.TrimRight(content, "\n")))
+		for _, anomaly := range fileAnomalies {
+			if anomaly.File == "" {
+				anomaly.File = fileName
+			}
+			anomalies = append(anomalies, anomaly)
+		}
+		fromComment := codeowners.Line{Type: "comment", File: fileName, Raw: "# from " + fileName}
+		emptyLine := codeowners.Line{Type: "empty", File: fileName}
+		linesOutsideSections, linesInSections := splitAtSections(
+			codeowners.PrefixPatterns(fileLines, filepath.ToSlash(filepath.Dir(fileName)), fileName),
+		)
+		// the empty line after them separates them from what follows
+		for len(linesOutsideSections) > 0 && linesOutsideSections[len(linesOutsideSections)-1].Type == "empty" {
+			linesOutsideSections = linesOutsideSections[:len(linesOutsideSections)-1]
+		}
+		if len(linesOutsideSections) > 0 {
+			lines = append(lines, fromComment)
+			lines = append(lines, linesOutsideSections...)
+			lines = append(lines, emptyLine)
+		}
+		if len(linesInSections) > 0 {
+			sectionLines = append(sectionLines, fromComment)
+			sectionLines = append(sectionLines, linesInSections...)
+			sectionLines = append(sectionLines, emptyLine)
+		}
+	}
+	return lines, sectionLines, anomalies, nil
+}
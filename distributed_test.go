@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributedOwners(t *testing.T) {
+	assert := assert.New(t)
+	t.Chdir(t.TempDir())
+	os.MkdirAll(".github", 0755)
+	os.MkdirAll("libs/sales/api", 0755)
+	os.MkdirAll("libs/sales-ops", 0755)
+	os.MkdirAll("node_modules/some-package", 0755)
+	os.WriteFile(".github/VIRTUAL-CODEOWNERS.txt", []byte("* @ch/ux\n"), 0644)
+	os.WriteFile(".github/virtual-teams.json", []byte(`{"ch/ux": ["davy"], "ch/sales": ["jane", "karl"]}`), 0644)
+	os.WriteFile("libs/sales/api/OWNERS.txt", []byte("* @karl\nopenapi.yaml karl\n"), 0644)
+	os.WriteFile("libs/sales/OWNERS.txt", []byte("*.js @ch/sales\n/api/ @jane\n"), 0644)
+	os.WriteFile("libs/sales-ops/OWNERS.txt", []byte("* @gregory\n"), 0644)
+	os.WriteFile("node_modules/some-package/OWNERS.txt", []byte("* @somebody\n"), 0644)
+
+	validate := "warn"
+	distributedOwners := "OWNERS.txt"
+	options := initCliOptions()
+	options.validate = &validate
+	options.distributedOwners = &distributedOwners
+
+	t.Run("merges the owners files in subdirectories, parents first", func(t *testing.T) {
+		message, error := cli(options)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Syntax errors found in the input:\n"+
+				"  libs/sales/api/OWNERS.txt:2, Invalid user 'karl': \"openapi.yaml karl\"\n"+
+				"\nWrote '.github/CODEOWNERS'\n",
+			message,
+		)
+		codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+		assert.Contains(string(codeOwners),
+			"* @davy\n"+
+				"\n# from libs/sales/OWNERS.txt\n"+
+				"/libs/sales/**/*.js @jane @karl\n"+
+				"/libs/sales/api/ @jane\n"+
+				"\n# from libs/sales/api/OWNERS.txt\n"+
+				"/libs/sales/api/ @karl\n"+
+				"/libs/sales/api/**/openapi.yaml karl\n"+
+				"\n# from libs/sales-ops/OWNERS.txt\n"+
+				"/libs/sales-ops/ @gregory\n",
+		)
+		assert.NotContains(string(codeOwners), "@somebody")
+	})
+
+	t.Run("owners files in subdirectories are sources for verify", func(t *testing.T) {
+		_, error := verifyCli([]string{"--distributedOwners", "OWNERS.txt"}, io.Discard)
+		assert.Nil(error)
+
+		os.WriteFile("libs/sales-ops/OWNERS.txt", []byte("* @jane\n"), 0644)
+		defer os.WriteFile("libs/sales-ops/OWNERS.txt", []byte("* @gregory\n"), 0644)
+		_, error = verifyCli([]string{"--distributedOwners", "OWNERS.txt"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'.github/CODEOWNERS' was generated from other sources or options than the current ones; run 'vcodeowners' to regenerate it", error.Error())
+	})
+
+	t.Run("moving an owners file to another directory makes CODEOWNERS outdated", func(t *testing.T) {
+		os.MkdirAll("libs/sales-ops-2", 0755)
+		os.Rename("libs/sales-ops/OWNERS.txt", "libs/sales-ops-2/OWNERS.txt")
+		defer os.Rename("libs/sales-ops-2/OWNERS.txt", "libs/sales-ops/OWNERS.txt")
+		_, error := verifyCli([]string{"--distributedOwners", "OWNERS.txt"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'.github/CODEOWNERS' was generated from other sources or options than the current ones; run 'vcodeowners' to regenerate it", error.Error())
+	})
+}
+
+func TestDistributedOwnersWithSections(t *testing.T) {
+	assert := assert.New(t)
+	t.Chdir(t.TempDir())
+	os.MkdirAll(".github", 0755)
+	os.MkdirAll("libs/a", 0755)
+	os.MkdirAll("libs/b", 0755)
+	os.WriteFile(".github/VIRTUAL-CODEOWNERS.txt", []byte("* @root\n\n[Docs] @docs\n*.md\n"), 0644)
+	os.WriteFile(".github/virtual-teams.json", []byte(`{}`), 0644)
+	os.WriteFile("libs/a/OWNERS.txt", []byte("*.go @gopher\n\n[Security] @security\n*.go\n"), 0644)
+	os.WriteFile("libs/b/OWNERS.txt", []byte("* @bee\n"), 0644)
+
+	distributedOwners := "OWNERS.txt"
+	options := initCliOptions()
+	options.distributedOwners = &distributedOwners
+
+	t.Run("puts the rules outside of sections before the first section, and sections of owners files after the rest", func(t *testing.T) {
+		_, error := cli(options)
+
+		assert.Nil(error)
+		codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+		assert.Contains(string(codeOwners),
+			"* @root\n"+
+				"\n# from libs/a/OWNERS.txt\n"+
+				"/libs/a/**/*.go @gopher\n"+
+				"\n# from libs/b/OWNERS.txt\n"+
+				"/libs/b/ @bee\n"+
+				"\n[Docs] @docs\n"+
+				"*.md\n"+
+				"\n# from libs/a/OWNERS.txt\n"+
+				"[Security] @security\n"+
+				"/libs/a/**/*.go\n",
+		)
+	})
+
+	t.Run("the lines of the owners files are in the section they end up in", func(t *testing.T) {
+		generated, error := generate(options)
+
+		assert.Nil(error)
+		var sections []string
+		for _, line := range generated.lines {
+			if line.Type == "rule" {
+				sections = append(sections, line.RulePattern+" in '"+line.RuleSection+"'")
+			}
+		}
+		assert.Equal([]string{
+			"* in ''",
+			"/libs/a/**/*.go in ''",
+			"/libs/b/ in ''",
+			"*.md in 'Docs'",
+			"/libs/a/**/*.go in 'Security'",
+		}, sections)
+	})
+}
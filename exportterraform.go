@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sverweij/vcodeowners/internal/teams"
+	"github.com/sverweij/vcodeowners/internal/terraform"
+)
+
+func permissionValid(permission string) bool {
+	var validPermissionOptions = map[string]bool{
+		"pull":     true,
+		"triage":   true,
+		"push":     true,
+		"maintain": true,
+		"admin":    true,
+	}
+	return validPermissionOptions[permission]
+}
+
+// exportTerraformCli writes Terraform for the integrations/github provider
+// that turns the virtual teams into real GitHub teams
+func exportTerraformCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("export-terraform", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners export-terraform [options]\n\n")
+		fmt.Fprint(output, "Writes Terraform (integrations/github provider) that turns the virtual teams into real GitHub teams\n\n")
+		flags.PrintDefaults()
+	}
+	virtualTeams := stringListFlag{values: []string{".github/virtual-teams.json"}}
+	flags.Var(&virtualTeams, "virtualTeams", "A JSON file listing teams and their members, or an LDIF export of a directory. Repeat it, or pass a directory or a glob to combine multiple files")
+	gitHubTeams := stringListFlag{}
+	flags.Var(&gitHubTeams, "gitHubTeams", "An export of the teams of a GitHub organization and their members. Its teams become virtual teams as they are. Repeat it, or pass a directory or a glob to combine multiple files")
+	teamMergeStrategy := flags.String("teamMergeStrategy", "error", "What to do with teams defined in more than one virtual teams file. error: exit, union: combine them, override: use the last one")
+	outputLocation := flags.String("output", "teams.tf", "The file to write the Terraform to")
+	repository := flags.String("repository", "", "Give the teams access to this repository (github_team_repository). Default: don't")
+	permission := flags.String("permission", "push", "The permission the teams get on the --repository: pull, triage, push, maintain, admin")
+	identities := flags.String("identities", "", "A JSON file mapping e-mail addresses to the handles of their owners per platform")
+	asOf := flags.String("asOf", "", "Only include team members that are active on this date (YYYY-MM-DD). Default: today")
+	dryRun := flags.Bool("dryRun", false, "Show the Terraform instead of writing it")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if !mergeStrategyValid(*teamMergeStrategy) {
+		return "",
+			fmt.Errorf("invalid teamMergeStrategy option '%s'; valid options: error, union, override", *teamMergeStrategy)
+	}
+	if !permissionValid(*permission) {
+		return "",
+			fmt.Errorf("invalid permission option '%s'; valid options: pull, triage, push, maintain, admin", *permission)
+	}
+	asOfDate, asOfError := parseAsOf(*asOf)
+	if asOfError != nil {
+		return "", asOfError
+	}
+
+	teamMap, teamSettings, warnings, teamMapError := readTeamMaps(virtualTeams.values, teamSourceOptions{
+		mergeStrategy: *teamMergeStrategy,
+		ldif:          teams.LDIFOptions{HandleAttribute: "githubUsername"},
+		gitHubTeams:   gitHubTeams.values,
+	})
+	if teamMapError != nil {
+		return "", teamMapError
+	}
+	identityMap, identitiesError := readIdentities(*identities)
+	if identitiesError != nil {
+		return "", identitiesError
+	}
+	teamMap, teamSettings, membershipWarnings := teams.ActiveOn(teamMap, teamSettings, asOfDate)
+
+	formatted := terraform.Format(teamMap, teamSettings, terraform.Options{
+		Repository: *repository,
+		Permission: *permission,
+		Identities: identityMap,
+	})
+	returnMessage := reportWarnings(append(warnings, membershipWarnings...), "warn")
+
+	if *dryRun {
+		return returnMessage + formatted + fmt.Sprintf("\nWrote '%s' (dry run)\n", *outputLocation), nil
+	}
+	writeError := os.WriteFile(*outputLocation, []byte(formatted), 0644)
+	if writeError != nil {
+		return "", writeError
+	}
+	return returnMessage + fmt.Sprintf("\nWrote '%s'\n", *outputLocation), nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportTerraformCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	outputFileName := filepath.Join(directory, "teams.tf")
+	os.WriteFile(teamsFileName, []byte(`{"ch/ux": ["davy", {"name": "john", "until": "2000-01-01"}]}`), 0644)
+
+	t.Run("writes Terraform for the active members", func(t *testing.T) {
+		message, error := exportTerraformCli(
+			[]string{"--virtualTeams", teamsFileName, "--output", outputFileName, "--repository", "shop", "--permission", "maintain"},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		assert.Contains(message, "Membership of team 'ch/ux' ended on 2000-01-01")
+		assert.Contains(message, "Wrote '"+outputFileName+"'")
+		formatted, _ := os.ReadFile(outputFileName)
+		assert.Contains(string(formatted), "resource \"github_team_membership\" \"ch_ux-davy\" {")
+		assert.NotContains(string(formatted), "john")
+		assert.Contains(string(formatted), "permission = \"maintain\"")
+	})
+
+	t.Run("dry run shows the Terraform", func(t *testing.T) {
+		dryRunFileName := filepath.Join(directory, "dry-run.tf")
+		message, error := exportTerraformCli(
+			[]string{"--virtualTeams", teamsFileName, "--output", dryRunFileName, "--dryRun"},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		assert.Contains(message, "resource \"github_team\" \"ch_ux\" {")
+		_, statError := os.Stat(dryRunFileName)
+		assert.NotNil(statError)
+	})
+
+	t.Run("active members on another date with --asOf", func(t *testing.T) {
+		message, error := exportTerraformCli(
+			[]string{"--virtualTeams", teamsFileName, "--output", outputFileName, "--asOf", "1999-12-31", "--dryRun"},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		assert.Contains(message, "username = \"john\"")
+	})
+
+	t.Run("error: invalid permission", func(t *testing.T) {
+		_, error := exportTerraformCli([]string{"--permission", "owner"}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("invalid permission option 'owner'; valid options: pull, triage, push, maintain, admin", error.Error())
+	})
+}
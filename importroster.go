@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// importRosterCli builds or updates a virtual teams file from a roster in
+// CSV format, and reports the membership changes
+func importRosterCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("import-roster", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners import-roster [options] <roster.csv>\n\n")
+		fmt.Fprint(output, "Builds or updates a virtual-teams.json from a roster in CSV format\n\n")
+		flags.PrintDefaults()
+	}
+	virtualTeams := flags.String("virtualTeams", ".github/virtual-teams.json", "The virtual teams file to build or update")
+	columns := flags.String("columns", "", "Which roster columns hold what, e.g. team=Squad,handle=GitHub login. Default: team,handle,email,role,start,end")
+	dryRun := flags.Bool("dryRun", false, "Just show the membership changes, don't write the virtual teams file")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return "", fmt.Errorf("import-roster needs exactly one roster file")
+	}
+	rosterColumns, columnsError := teams.ParseRosterColumns(*columns)
+	if columnsError != nil {
+		return "", columnsError
+	}
+
+	rosterBytes, rosterReadError := os.ReadFile(flags.Arg(0))
+	if rosterReadError != nil {
+		return "", rosterReadError
+	}
+	rosterMap, rosterSettings, rosterParseError := teams.ParseRoster(string(rosterBytes), rosterColumns)
+	if rosterParseError != nil {
+		return "", fmt.Errorf("%s: %w", flags.Arg(0), rosterParseError)
+	}
+
+	teamMap := teams.Map{}
+	teamSettings := teams.Settings{}
+	teamMapBytes, teamMapReadError := os.ReadFile(*virtualTeams)
+	if teamMapReadError == nil {
+		var teamMapParseError error
+		teamMap, teamSettings, teamMapParseError = teams.ParseWithSettings(string(teamMapBytes))
+		if teamMapParseError != nil {
+			return "", fmt.Errorf("%s: %w", *virtualTeams, teamMapParseError)
+		}
+	} else if !errors.Is(teamMapReadError, fs.ErrNotExist) {
+		return "", teamMapReadError
+	}
+
+	updatedMap, updatedSettings := teams.UpdateFromRoster(teamMap, teamSettings, rosterMap, rosterSettings)
+	returnMessage := teams.DiffMembers(teamMap, updatedMap).String()
+
+	if *dryRun {
+		return returnMessage + fmt.Sprintf("\nWrote '%s' (dry run)\n", *virtualTeams), nil
+	}
+	formatted, formatError := teams.FormatLike(string(teamMapBytes), updatedMap, updatedSettings)
+	if formatError != nil {
+		return "", formatError
+	}
+	writeError := os.WriteFile(*virtualTeams, []byte(formatted), 0644)
+	if writeError != nil {
+		return "", writeError
+	}
+	return returnMessage + fmt.Sprintf("\nWrote '%s'\n", *virtualTeams), nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportRosterCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	rosterFileName := filepath.Join(directory, "roster.csv")
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	os.WriteFile(rosterFileName, []byte(
+		"Squad,GitHub,E-mail,Role\n"+
+			"ch/sales,jane-doe,jane@example.com,maintainer\n"+
+			"ch/sales,gregory,,\n",
+	), 0644)
+
+	t.Run("dry run shows the changes and leaves the virtual teams file alone", func(t *testing.T) {
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane-doe", "karl"], "ch/ux": ["davy"]}`), 0644)
+
+		message, error := importRosterCli(
+			[]string{"--virtualTeams", teamsFileName, "--columns", "team=Squad,handle=GitHub,email=E-mail", "--dryRun", rosterFileName},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		assert.Equal("ch/sales\n  + gregory\n  - karl\n\nWrote '"+teamsFileName+"' (dry run)\n", message)
+		teamMapBytes, _ := os.ReadFile(teamsFileName)
+		assert.Equal(`{"ch/sales": ["jane-doe", "karl"], "ch/ux": ["davy"]}`, string(teamMapBytes))
+	})
+
+	t.Run("updates the virtual teams file", func(t *testing.T) {
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane-doe", "karl"], "ch/ux": ["davy"]}`), 0644)
+
+		_, error := importRosterCli(
+			[]string{"--virtualTeams", teamsFileName, "--columns", "team=Squad,handle=GitHub,email=E-mail", rosterFileName},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		teamMapBytes, _ := os.ReadFile(teamsFileName)
+		assert.Equal(
+			`{"ch/sales": {"members": ["jane-doe", "gregory"], "maintainers": ["jane-doe"]}, "ch/ux": ["davy"]}`,
+			string(teamMapBytes),
+		)
+	})
+
+	t.Run("keeps the layout of the virtual teams file", func(t *testing.T) {
+		os.WriteFile(teamsFileName, []byte("{\n    \"ch/ux\": [\"davy\"],\n    \"ch/sales\": [\n        \"jane-doe\"\n    ]\n}\n"), 0644)
+
+		_, error := importRosterCli(
+			[]string{"--virtualTeams", teamsFileName, "--columns", "team=Squad,handle=GitHub", rosterFileName},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		teamMapBytes, _ := os.ReadFile(teamsFileName)
+		assert.Equal(
+			"{\n    \"ch/ux\": [\"davy\"],\n    \"ch/sales\": {\n        \"members\": [\n            \"jane-doe\",\n            \"gregory\"\n        ],\n"+
+				"        \"maintainers\": [\n            \"jane-doe\"\n        ]\n    }\n}\n",
+			string(teamMapBytes),
+		)
+	})
+
+	t.Run("builds a new virtual teams file", func(t *testing.T) {
+		newTeamsFileName := filepath.Join(directory, "new-virtual-teams.json")
+
+		message, error := importRosterCli(
+			[]string{"--virtualTeams", newTeamsFileName, "--columns", "team=Squad,handle=GitHub", rosterFileName},
+			io.Discard,
+		)
+
+		assert.Nil(error)
+		assert.Equal("ch/sales\n  + jane-doe\n  + gregory\n\nWrote '"+newTeamsFileName+"'\n", message)
+	})
+
+	t.Run("error: no roster file", func(t *testing.T) {
+		_, error := importRosterCli([]string{"--virtualTeams", teamsFileName}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("import-roster needs exactly one roster file", error.Error())
+	})
+
+	t.Run("error: roster in another shape than the columns say", func(t *testing.T) {
+		_, error := importRosterCli([]string{"--virtualTeams", teamsFileName, rosterFileName}, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal(rosterFileName+": the roster has no 'team' column", error.Error())
+	})
+}
+
+func TestSubcommandName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("import-roster", subcommandName([]string{"vcodeowners", "import-roster", "roster.csv"}))
+	assert.Equal("", subcommandName([]string{"vcodeowners", "--dryRun"}))
+	assert.Equal("", subcommandName([]string{"vcodeowners"}))
+}
@@ -0,0 +1,338 @@
+package backport
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+	"github.com/sverweij/vcodeowners/internal/textdiff"
+)
+
+// Result holds the sources with the hand edits back-ported, and a
+// description of each change
+type Result struct {
+	VirtualCodeOwners string
+	MembershipEdits   []MembershipEdit
+	Changes           []string
+}
+
+// MembershipEdit adds a member to, or removes one from, a virtual team
+type MembershipEdit struct {
+	Team   string
+	Member string
+	Remove bool
+}
+
+// lineKey identifies a line for aligning the generated and the edited
+// CODEOWNERS: rules by their pattern, section headings by their name and
+// other lines by their content. Owners don't count, so lines with other
+// owners still line up.
+func lineKey(line codeowners.Line) string {
+	switch line.Type {
+	case "rule":
+		return "rule " + line.RulePattern
+	case "section-heading":
+		return "section " + line.SectionName
+	default:
+		return line.Type + " " + strings.TrimSpace(line.Raw)
+	}
+}
+
+// getRelevantLines returns the indexes of the lines that end up in a
+// CODEOWNERS file, except empty ones
+func getRelevantLines(cst codeowners.CST) []int {
+	var indexes []int
+	for i, line := range cst {
+		if line.Type != "empty" && line.Type != "ignorable-comment" {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func ownerNames(owners []codeowners.Owner) []string {
+	var names []string
+	for _, owner := range owners {
+		if owner.Type == "user-or-group" || owner.Type == "e-mail" {
+			names = append(names, owner.Name)
+		}
+	}
+	return names
+}
+
+// backporter holds what's needed to map differences back to the sources
+type backporter struct {
+	virtualLines codeowners.CST
+	teamMap      teams.Map
+	options      teams.Options
+	// owner names added and removed per virtual line
+	added   map[int][]string
+	removed map[int][]string
+	deleted map[int]bool
+	// per virtual line, the owners each owner on that line expands to
+	expansions map[int][][]string
+	result     Result
+	handled    map[string]bool
+	modified   map[int]bool
+}
+
+// expand returns what each owner on the virtual line expands to
+func (b *backporter) expand(index int) [][]string {
+	if expansions, found := b.expansions[index]; found {
+		return expansions
+	}
+	var expansions [][]string
+	line := b.virtualLines[index]
+	for _, owner := range line.Owners {
+		singleOwnerLine := line
+		singleOwnerLine.Owners = []codeowners.Owner{owner}
+		expanded, _, error := teams.ApplyWithOptions(codeowners.CST{singleOwnerLine}, b.teamMap, b.options)
+		if error != nil || owner.Type == "exclusion" {
+			expansions = append(expansions, nil)
+			continue
+		}
+		expansions = append(expansions, ownerNames(expanded[0].Owners))
+	}
+	b.expansions[index] = expansions
+	return expansions
+}
+
+// getOrigins returns the indexes of the owners on the virtual line the
+// owner name comes from
+func (b *backporter) getOrigins(index int, name string) []int {
+	var origins []int
+	for i, expansion := range b.expand(index) {
+		if slices.Contains(expansion, name) {
+			origins = append(origins, i)
+		}
+	}
+	return origins
+}
+
+// getTeam returns the name of the virtual team the owner is, when it's a
+// virtual team whose members get listed (so not one with a real handle)
+func (b *backporter) getTeam(owner codeowners.Owner) (string, bool) {
+	team := strings.TrimPrefix(owner.Name, "@")
+	if owner.Type != "user-or-group" || owner.Role != "" || b.teamMap[team] == nil || b.options.Settings[team].Handle != "" {
+		return "", false
+	}
+	return team, true
+}
+
+// getTeamLines returns the indexes of the virtual lines the team is on
+func (b *backporter) getTeamLines(team string) []int {
+	var indexes []int
+	for i, line := range b.virtualLines {
+		for _, owner := range line.Owners {
+			if lineTeam, isTeam := b.getTeam(owner); isTeam && lineTeam == team {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// isChangedEverywhere returns true when the name was added to (or removed
+// from) every line the team is on
+func (b *backporter) isChangedEverywhere(team string, name string, changes map[int][]string) bool {
+	for _, index := range b.getTeamLines(team) {
+		if !b.deleted[index] && !slices.Contains(changes[index], name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *backporter) markHandled(team string, name string) {
+	for _, index := range b.getTeamLines(team) {
+		b.handled[fmt.Sprintf("%d %s", index, name)] = true
+	}
+}
+
+func memberName(ownerName string) string {
+	if codeowners.ParseOwner(ownerName).Type == "e-mail" {
+		return ownerName
+	}
+	return strings.TrimPrefix(ownerName, "@")
+}
+
+func (b *backporter) editMembership(team string, member string, remove bool) {
+	b.result.MembershipEdits = append(b.result.MembershipEdits, MembershipEdit{Team: team, Member: member, Remove: remove})
+	verb := "add"
+	if remove {
+		verb = "remove"
+	}
+	b.result.Changes = append(b.result.Changes, fmt.Sprintf("team '%s': %s %s", team, verb, member))
+}
+
+func (b *backporter) setOwners(index int, owners []codeowners.Owner, change string) {
+	line := b.virtualLines[index]
+	// a section heading without owners would print as it was
+	line.Owners = append([]codeowners.Owner{}, owners...)
+	if line.Spaces == "" && len(owners) > 0 {
+		line.Spaces = " "
+	}
+	b.virtualLines[index] = line
+	b.modified[index] = true
+	b.result.Changes = append(b.result.Changes, fmt.Sprintf("line %d: %s", line.LineNo, change))
+}
+
+func (b *backporter) backportRemoval(index int, name string) {
+	if b.handled[fmt.Sprintf("%d %s", index, name)] {
+		return
+	}
+	line := b.virtualLines[index]
+	origins := b.getOrigins(index, name)
+
+	if len(origins) == 1 {
+		origin := line.Owners[origins[0]]
+		if team, isTeam := b.getTeam(origin); isTeam && b.isChangedEverywhere(team, name, b.removed) {
+			b.editMembership(team, memberName(name), true)
+			b.markHandled(team, name)
+			return
+		}
+		if origin.Name == name {
+			b.setOwners(index, slices.Delete(slices.Clone(line.Owners), origins[0], origins[0]+1), "remove "+name)
+			return
+		}
+	}
+	b.setOwners(index, append(slices.Clone(line.Owners), codeowners.Owner{Type: "exclusion", Name: "-" + name}), "exclude "+name)
+}
+
+func (b *backporter) backportAddition(index int, name string) {
+	if b.handled[fmt.Sprintf("%d %s", index, name)] {
+		return
+	}
+	line := b.virtualLines[index]
+
+	for _, owner := range line.Owners {
+		if team, isTeam := b.getTeam(owner); isTeam && b.isChangedEverywhere(team, name, b.added) {
+			b.editMembership(team, memberName(name), false)
+			b.markHandled(team, name)
+			return
+		}
+	}
+	if exclusion := slices.IndexFunc(line.Owners, func(owner codeowners.Owner) bool {
+		return owner.Type == "exclusion" && owner.ExcludedName() == name
+	}); exclusion >= 0 {
+		b.setOwners(index, slices.Delete(slices.Clone(line.Owners), exclusion, exclusion+1), "don't exclude "+name)
+		return
+	}
+	b.setOwners(index, append(slices.Clone(line.Owners), codeowners.ParseOwner(name)), "add "+name)
+}
+
+// Backport compares the CODEOWNERS as edited by hand with the one generated
+// from the virtual lines and the team map, and maps each difference back
+// to the sources:
+//   - an owner removed from every line a team is on is removed from the
+//     team; otherwise it's removed from (or excluded on) the line
+//   - an owner added to every line a team is on is added to the team;
+//     otherwise it's added to the line
+//   - lines removed or added by hand are removed from or added to
+//     VIRTUAL-CODEOWNERS.txt
+//
+// Lines line up by their pattern (rules), name (section headings) or
+// content (other lines).
+func Backport(virtualLines codeowners.CST, teamMap teams.Map, options teams.Options, edited codeowners.CST) (Result, error) {
+	generated, _, error := teams.ApplyWithOptions(virtualLines, teamMap, options)
+	if error != nil {
+		return Result{}, error
+	}
+	b := backporter{
+		virtualLines: slices.Clone(virtualLines),
+		teamMap:      teamMap,
+		options:      options,
+		added:        map[int][]string{},
+		removed:      map[int][]string{},
+		deleted:      map[int]bool{},
+		expansions:   map[int][][]string{},
+		result:       Result{},
+		handled:      map[string]bool{},
+		modified:     map[int]bool{},
+	}
+
+	generatedIndexes := getRelevantLines(generated)
+	editedIndexes := getRelevantLines(edited)
+	var generatedKeys, editedKeys []string
+	for _, index := range generatedIndexes {
+		generatedKeys = append(generatedKeys, lineKey(generated[index]))
+	}
+	for _, index := range editedIndexes {
+		editedKeys = append(editedKeys, lineKey(edited[index]))
+	}
+
+	// lines added by hand go after the line before them that's in both
+	insertions := map[int][]string{}
+	previous := -1
+	matches := textdiff.Align(generatedKeys, editedKeys)
+	matchedGenerated := map[int]bool{}
+	nextEdited := 0
+	for _, match := range append(matches, textdiff.Match{From: len(generatedKeys), To: len(editedKeys)}) {
+		for ; nextEdited < match.To; nextEdited++ {
+			insertions[previous] = append(insertions[previous], edited[editedIndexes[nextEdited]].Raw)
+		}
+		if match.From == len(generatedKeys) {
+			break
+		}
+		matchedGenerated[match.From] = true
+		generatedLine := generated[generatedIndexes[match.From]]
+		editedLine := edited[editedIndexes[match.To]]
+		index := generatedIndexes[match.From]
+		generatedOwners := ownerNames(generatedLine.Owners)
+		editedOwners := ownerNames(editedLine.Owners)
+		for _, name := range generatedOwners {
+			if !slices.Contains(editedOwners, name) {
+				b.removed[index] = append(b.removed[index], name)
+			}
+		}
+		for _, name := range editedOwners {
+			if !slices.Contains(generatedOwners, name) {
+				b.added[index] = append(b.added[index], name)
+			}
+		}
+		previous = index
+		nextEdited = match.To + 1
+	}
+	for i, index := range generatedIndexes {
+		if !matchedGenerated[i] {
+			b.deleted[index] = true
+		}
+	}
+
+	for index := range b.virtualLines {
+		if b.deleted[index] {
+			continue
+		}
+		for _, name := range b.removed[index] {
+			b.backportRemoval(index, name)
+		}
+		for _, name := range b.added[index] {
+			b.backportAddition(index, name)
+		}
+	}
+
+	var output []string
+	for _, raw := range insertions[-1] {
+		output = append(output, raw)
+		b.result.Changes = append(b.result.Changes, fmt.Sprintf("at the start: insert \"%s\"", raw))
+	}
+	for index, line := range b.virtualLines {
+		switch {
+		case b.deleted[index]:
+			b.result.Changes = append(b.result.Changes, fmt.Sprintf("line %d: delete \"%s\"", line.LineNo, line.Raw))
+		case b.modified[index]:
+			output = append(output, strings.TrimSuffix(line.String(), "\n"))
+		default:
+			output = append(output, line.Raw)
+		}
+		for _, raw := range insertions[index] {
+			output = append(output, raw)
+			b.result.Changes = append(b.result.Changes, fmt.Sprintf("after line %d: insert \"%s\"", line.LineNo, raw))
+		}
+	}
+	b.result.VirtualCodeOwners = strings.Join(output, "\n")
+	return b.result, nil
+}
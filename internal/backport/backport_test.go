@@ -0,0 +1,111 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+func backport(virtual string, teamMap teams.Map, edited string) (Result, error) {
+	virtualLines, _ := codeowners.Parse(virtual)
+	editedLines, _ := codeowners.Parse(edited)
+	return Backport(virtualLines, teamMap, teams.Options{}, editedLines)
+}
+
+func TestBackport(t *testing.T) {
+	teamMap := teams.Map{
+		"sales":   {"jan", "pier"},
+		"support": {"tjorus", "korneel"},
+	}
+	virtual := "#! not in CODEOWNERS\n# sales\n/sales/ @sales\n/sales/leads/ @sales @support\n/docs/  @ghost\n"
+
+	t.Run("no changes when nothing was edited", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier\n/sales/leads/ @jan @pier @korneel @tjorus\n/docs/ @ghost\n\n")
+		assert.Nil(error)
+		assert.Equal(virtual, result.VirtualCodeOwners)
+		assert.Nil(result.Changes)
+		assert.Nil(result.MembershipEdits)
+	})
+
+	t.Run("owner removed from every line of a team leaves the team", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan\n/sales/leads/ @jan @korneel @tjorus\n/docs/ @ghost\n")
+		assert.Nil(error)
+		assert.Equal(virtual, result.VirtualCodeOwners)
+		assert.Equal([]MembershipEdit{{Team: "sales", Member: "pier", Remove: true}}, result.MembershipEdits)
+		assert.Equal([]string{"team 'sales': remove pier"}, result.Changes)
+	})
+
+	t.Run("owner removed from one line of a team gets excluded there", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier\n/sales/leads/ @jan @korneel @tjorus\n/docs/ @ghost\n")
+		assert.Nil(error)
+		assert.Equal("#! not in CODEOWNERS\n# sales\n/sales/ @sales\n/sales/leads/ @sales @support -@pier\n/docs/  @ghost\n", result.VirtualCodeOwners)
+		assert.Equal([]string{"line 4: exclude @pier"}, result.Changes)
+	})
+
+	t.Run("owner on the line itself gets removed from it", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier\n/sales/leads/ @jan @pier @korneel @tjorus\n/docs/ @ghost @karl\n")
+		assert.Nil(error)
+		assert.Equal("#! not in CODEOWNERS\n# sales\n/sales/ @sales\n/sales/leads/ @sales @support\n/docs/  @ghost @karl\n", result.VirtualCodeOwners)
+		assert.Equal([]string{"line 5: add @karl"}, result.Changes)
+
+		result, error = backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier\n/sales/leads/ @jan @pier @korneel @tjorus\n/docs/ @karl\n")
+		assert.Nil(error)
+		assert.Equal("#! not in CODEOWNERS\n# sales\n/sales/ @sales\n/sales/leads/ @sales @support\n/docs/  @karl\n", result.VirtualCodeOwners)
+		assert.Equal([]string{"line 5: remove @ghost", "line 5: add @karl"}, result.Changes)
+	})
+
+	t.Run("owner added to every line of a team joins the team", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier @karl\n/sales/leads/ @jan @pier @karl @korneel @tjorus\n/docs/ @ghost\n")
+		assert.Nil(error)
+		assert.Equal(virtual, result.VirtualCodeOwners)
+		assert.Equal([]MembershipEdit{{Team: "sales", Member: "karl"}}, result.MembershipEdits)
+	})
+
+	t.Run("adding an excluded owner drops the exclusion", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport("/sales/ @sales -@pier\n/docs/ @sales\n", teamMap, "/sales/ @jan @pier\n/docs/ @jan @pier\n")
+		assert.Nil(error)
+		assert.Equal("/sales/ @sales\n/docs/ @sales\n", result.VirtualCodeOwners)
+		assert.Equal([]string{"line 1: don't exclude @pier"}, result.Changes)
+	})
+
+	t.Run("lines added and removed by hand", func(t *testing.T) {
+		assert := assert.New(t)
+		result, error := backport(virtual, teamMap,
+			"# sales\n/sales/ @jan @pier\n/sales/new/ @karl\n/docs/ @ghost\n")
+		assert.Nil(error)
+		assert.Equal("#! not in CODEOWNERS\n# sales\n/sales/ @sales\n/sales/new/ @karl\n/docs/  @ghost\n", result.VirtualCodeOwners)
+		assert.Equal([]string{"after line 3: insert \"/sales/new/ @karl\"", "line 4: delete \"/sales/leads/ @sales @support\""}, result.Changes)
+	})
+}
+
+func TestApplyMembershipEdits(t *testing.T) {
+	assert := assert.New(t)
+	teamMap := teams.Map{"sales": {"jan", "pier"}}
+	settings := teams.Settings{"sales": {Maintainers: []string{"pier"}, Roles: map[string][]string{"leads": {"jan", "pier"}}}}
+
+	editedMap, editedSettings := ApplyMembershipEdits(teamMap, settings, []MembershipEdit{
+		{Team: "sales", Member: "pier", Remove: true},
+		{Team: "sales", Member: "karl"},
+		{Team: "sales", Member: "jan"},
+	})
+	assert.Equal(teams.Map{"sales": {"jan", "karl"}}, editedMap)
+	assert.Nil(editedSettings["sales"].Maintainers)
+	assert.Equal([]string{"jan"}, editedSettings["sales"].Roles["leads"])
+	// the originals stay as they were
+	assert.Equal(teams.Map{"sales": {"jan", "pier"}}, teamMap)
+	assert.Equal([]string{"pier"}, settings["sales"].Maintainers)
+}
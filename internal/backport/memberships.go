@@ -0,0 +1,58 @@
+package backport
+
+import (
+	"slices"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// ApplyMembershipEdits returns copies of the team map and settings with the
+// membership edits applied. Removing a member also removes them from the
+// team's maintainers, roles, extra members and membership windows.
+func ApplyMembershipEdits(teamMap teams.Map, settings teams.Settings, edits []MembershipEdit) (teams.Map, teams.Settings) {
+	editedMap := teams.Map{}
+	for team, members := range teamMap {
+		editedMap[team] = slices.Clone(members)
+	}
+	editedSettings := teams.Settings{}
+	for team, teamSettings := range settings {
+		editedSettings[team] = teamSettings
+	}
+
+	for _, edit := range edits {
+		if !edit.Remove {
+			if !slices.Contains(editedMap[edit.Team], edit.Member) {
+				editedMap[edit.Team] = append(editedMap[edit.Team], edit.Member)
+			}
+			continue
+		}
+		editedMap[edit.Team] = slices.DeleteFunc(editedMap[edit.Team], func(member string) bool {
+			return member == edit.Member
+		})
+		teamSettings, found := editedSettings[edit.Team]
+		if !found {
+			continue
+		}
+		teamSettings.ExtraMembers = codeowners.Difference(teamSettings.ExtraMembers, []string{edit.Member})
+		teamSettings.Maintainers = codeowners.Difference(teamSettings.Maintainers, []string{edit.Member})
+		if teamSettings.Roles != nil {
+			roles := map[string][]string{}
+			for role, roleMembers := range teamSettings.Roles {
+				roles[role] = codeowners.Difference(roleMembers, []string{edit.Member})
+			}
+			teamSettings.Roles = roles
+		}
+		if _, hasWindow := teamSettings.Windows[edit.Member]; hasWindow {
+			windows := map[string]teams.Window{}
+			for member, window := range teamSettings.Windows {
+				if member != edit.Member {
+					windows[member] = window
+				}
+			}
+			teamSettings.Windows = windows
+		}
+		editedSettings[edit.Team] = teamSettings
+	}
+	return editedMap, editedSettings
+}
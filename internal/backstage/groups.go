@@ -0,0 +1,62 @@
+package backstage
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatGroups returns the virtual teams as Backstage Group entities, one
+// YAML document per team. Teams named namespace/name end up in that
+// namespace (with any further slashes in the name replaced by dashes).
+// Members are referred to by their handle; e-mail addresses aren't valid
+// entity names, so members listed by e-mail address are left out. Teams
+// with a handle get it as their github.com/team-slug annotation.
+func FormatGroups(teamMap teams.Map, settings teams.Settings) (string, error) {
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+
+	for _, team := range slices.Sorted(maps.Keys(teamMap)) {
+		namespace, name, found := strings.Cut(team, "/")
+		if !found {
+			namespace, name = "", team
+		}
+		name = strings.ReplaceAll(name, "/", "-")
+		members := []string{}
+		for _, member := range teamMap[team] {
+			if codeowners.ParseOwner(member).Type != "e-mail" {
+				members = append(members, member)
+			}
+		}
+		entity := Entity{
+			APIVersion: "backstage.io/v1alpha1",
+			Kind:       "Group",
+			Metadata: Metadata{
+				Name:        name,
+				Namespace:   namespace,
+				Description: "generated by vcodeowners from the virtual team '" + team + "'",
+			},
+			Spec: Spec{
+				Type:     "team",
+				Children: []string{},
+				Members:  members,
+			},
+		}
+		if handle := settings[team].Handle; handle != "" {
+			entity.Metadata.Annotations = map[string]string{"github.com/team-slug": strings.TrimPrefix(handle, "@")}
+		}
+		if error := encoder.Encode(entity); error != nil {
+			return "", error
+		}
+	}
+	if error := encoder.Close(); error != nil {
+		return "", error
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,55 @@
+package backstage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+func TestFormatGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("teams become groups", func(t *testing.T) {
+		formatted, error := FormatGroups(
+			teams.Map{"ch/sales": {"jane-doe", "karl@example.com"}, "ux": {"davy"}},
+			teams.Settings{"ch/sales": {Handle: "@cloud-heroes/sales"}},
+		)
+
+		assert.Nil(error)
+		assert.Equal(`apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: sales
+  namespace: ch
+  description: generated by vcodeowners from the virtual team 'ch/sales'
+  annotations:
+    github.com/team-slug: cloud-heroes/sales
+spec:
+  type: team
+  children: []
+  members:
+    - jane-doe
+---
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: ux
+  description: generated by vcodeowners from the virtual team 'ux'
+spec:
+  type: team
+  children: []
+  members:
+    - davy
+`, formatted)
+	})
+
+	t.Run("round trips through Parse and TeamSources", func(t *testing.T) {
+		teamMap := teams.Map{"ch/sales": {"jane-doe", "karl"}, "ux": {}}
+		formatted, _ := FormatGroups(teamMap, nil)
+		entities, error := Parse(formatted, "groups.yaml")
+
+		assert.Nil(error)
+		assert.Equal(teamMap, TeamSources(NewCatalog(entities))[0].Map)
+	})
+}
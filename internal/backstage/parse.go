@@ -0,0 +1,167 @@
+package backstage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entity is the part of a Backstage catalog entity vcodeowners uses
+type Entity struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       Spec     `yaml:"spec"`
+	// The file the entity was read from, and the line it starts on
+	Location string `yaml:"-"`
+	Line     int    `yaml:"-"`
+}
+
+// Metadata of a Backstage catalog entity
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Spec holds the fields of the specs of Group, User and Component entities
+// vcodeowners uses
+type Spec struct {
+	// Group & Component
+	Type string `yaml:"type,omitempty"`
+	// Group
+	Profile  map[string]string `yaml:"profile,omitempty"`
+	Children []string          `yaml:"children"`
+	Members  []string          `yaml:"members,omitempty"`
+	// Component
+	Owner string `yaml:"owner,omitempty"`
+}
+
+// Parse parses a Backstage catalog file (e.g. catalog-info.yaml), which can
+// contain more than one entity, each in its own YAML document. The location
+// is the path of the file, which relative source locations are relative to.
+func Parse(catalog string, location string) ([]Entity, error) {
+	var entities []Entity
+	decoder := yaml.NewDecoder(strings.NewReader(catalog))
+
+	for {
+		var document yaml.Node
+		error := decoder.Decode(&document)
+		if errors.Is(error, io.EOF) {
+			break
+		}
+		if error != nil {
+			return nil, fmt.Errorf("%s: %w", location, error)
+		}
+		var entity *Entity
+		if decodeError := document.Decode(&entity); decodeError != nil {
+			return nil, fmt.Errorf("%s: %w", location, decodeError)
+		}
+		if entity == nil {
+			continue
+		}
+		if entity.Kind == "" || entity.Metadata.Name == "" {
+			return nil, fmt.Errorf("%s: Backstage entities should have a kind and a metadata.name", location)
+		}
+		entity.Location = location
+		entity.Line = document.Content[0].Line
+		entities = append(entities, *entity)
+	}
+	return entities, nil
+}
+
+// entityRef is a reference to an entity, e.g. group:default/sales
+type entityRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// parseEntityRef parses a reference to an entity in the [kind:][namespace/]name
+// format. References without a kind are of the default kind, those without
+// a namespace are in the default namespace.
+func parseEntityRef(ref string, defaultKind string) entityRef {
+	kind, rest, found := strings.Cut(ref, ":")
+	if !found {
+		kind, rest = defaultKind, ref
+	}
+	namespace, name, found := strings.Cut(rest, "/")
+	if !found {
+		namespace, name = "default", rest
+	}
+	return entityRef{
+		Kind:      strings.ToLower(kind),
+		Namespace: strings.ToLower(namespace),
+		Name:      name,
+	}
+}
+
+func (entity Entity) ref() entityRef {
+	namespace := entity.Metadata.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return entityRef{
+		Kind:      strings.ToLower(entity.Kind),
+		Namespace: strings.ToLower(namespace),
+		Name:      entity.Metadata.Name,
+	}
+}
+
+// teamName returns the name of the virtual team for a group: its name, or
+// namespace/name when it's not in the default namespace
+func (ref entityRef) teamName() string {
+	if ref.Namespace == "default" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// Catalog is a set of Backstage entities, indexed by their reference
+type Catalog struct {
+	Entities []Entity
+	byRef    map[entityRef]Entity
+}
+
+// NewCatalog returns a catalog of the entities
+func NewCatalog(entities []Entity) Catalog {
+	byRef := map[entityRef]Entity{}
+	for _, entity := range entities {
+		ref := entity.ref()
+		ref.Name = strings.ToLower(ref.Name)
+		byRef[ref] = entity
+	}
+	return Catalog{Entities: entities, byRef: byRef}
+}
+
+// HasComponents returns true when the catalog has Component entities
+func (catalog Catalog) HasComponents() bool {
+	return slices.ContainsFunc(catalog.Entities, func(entity Entity) bool {
+		return strings.EqualFold(entity.Kind, "component")
+	})
+}
+
+func (catalog Catalog) lookup(ref entityRef) (Entity, bool) {
+	ref.Name = strings.ToLower(ref.Name)
+	entity, found := catalog.byRef[ref]
+	return entity, found
+}
+
+// userHandle returns the handle of the user the reference points to: the
+// login from its github.com/user-login (or gitlab.com/user-login)
+// annotation, or its name when it doesn't have one
+func (catalog Catalog) userHandle(ref entityRef) string {
+	if user, found := catalog.lookup(ref); found {
+		for _, annotation := range []string{"github.com/user-login", "gitlab.com/user-login"} {
+			if login := user.Metadata.Annotations[annotation]; login != "" {
+				return strings.TrimPrefix(login, "@")
+			}
+		}
+	}
+	return ref.Name
+}
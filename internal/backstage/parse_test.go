@@ -0,0 +1,85 @@
+package backstage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("all entities in the file", func(t *testing.T) {
+		entities, error := Parse(`---
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: sales
+spec:
+  type: team
+  children: []
+  members: [jane]
+---
+# an empty document
+---
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: sales-app
+  annotations:
+    backstage.io/source-location: dir:.
+spec:
+  owner: sales
+`, "libs/sales/catalog-info.yaml")
+
+		assert.Nil(error)
+		assert.Equal([]Entity{
+			{
+				APIVersion: "backstage.io/v1alpha1",
+				Kind:       "Group",
+				Metadata:   Metadata{Name: "sales"},
+				Spec:       Spec{Type: "team", Children: []string{}, Members: []string{"jane"}},
+				Location:   "libs/sales/catalog-info.yaml",
+				Line:       2,
+			},
+			{
+				APIVersion: "backstage.io/v1alpha1",
+				Kind:       "Component",
+				Metadata:   Metadata{Name: "sales-app", Annotations: map[string]string{"backstage.io/source-location": "dir:."}},
+				Spec:       Spec{Owner: "sales"},
+				Location:   "libs/sales/catalog-info.yaml",
+				Line:       13,
+			},
+		}, entities)
+	})
+
+	t.Run("error: not YAML", func(t *testing.T) {
+		_, error := Parse("kind: [Group", "catalog-info.yaml")
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: not an entity", func(t *testing.T) {
+		_, error := Parse("name: sales\n", "catalog-info.yaml")
+
+		assert.NotNil(error)
+		assert.Equal("catalog-info.yaml: Backstage entities should have a kind and a metadata.name", error.Error())
+	})
+}
+
+func TestParseEntityRef(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(entityRef{Kind: "group", Namespace: "default", Name: "sales"}, parseEntityRef("sales", "group"))
+	assert.Equal(entityRef{Kind: "user", Namespace: "ch", Name: "jane"}, parseEntityRef("User:CH/jane", "group"))
+	assert.Equal("ch/sales", parseEntityRef("group:ch/sales", "user").teamName())
+	assert.Equal("sales", parseEntityRef("group:default/sales", "user").teamName())
+}
+
+func TestHasComponents(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(NewCatalog([]Entity{{Kind: "Group"}, {Kind: "Component"}}).HasComponents())
+	assert.False(NewCatalog([]Entity{{Kind: "Group"}}).HasComponents())
+	assert.False(NewCatalog(nil).HasComponents())
+}
@@ -0,0 +1,116 @@
+package backstage
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+const sourceLocationAnnotation = "backstage.io/source-location"
+
+// matches the path in the URLs of directories (tree) and files (blob) on
+// GitHub and GitLab, e.g. https://github.com/org/repo/tree/main/libs/sales/
+var sourceURLPathPattern = regexp.MustCompile(`/(?:-/)?(tree|blob)/[^/]+/?(.*)$`)
+
+// matches what goes before the host and after the repository in the URL
+// of a git remote, e.g. 'git@' and '.git' in git@github.com:org/repo.git
+var remoteURLPattern = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^/:]+)(?::[0-9]+/|:|/)(.+?)(?:\.git)?/?$`)
+
+// RepositoryName returns the host and path of a repository from the URL of
+// a git remote or of the repository on GitHub or GitLab, e.g.
+// 'github.com/org/repo' for both git@github.com:org/repo.git and
+// https://github.com/org/repo. It returns "" when it isn't such a URL.
+func RepositoryName(url string) string {
+	match := remoteURLPattern.FindStringSubmatch(strings.TrimSpace(url))
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1] + "/" + match[2])
+}
+
+// getRulePattern returns the CODEOWNERS pattern for the source location of
+// a component. Directories get a trailing slash; the root of the repository
+// is '*'. When the repository (see RepositoryName) isn't empty, URLs should
+// point into it.
+func getRulePattern(sourceLocation string, catalogLocation string, repository string) (string, error) {
+	var path string
+	isDirectory := true
+
+	if url, found := strings.CutPrefix(sourceLocation, "url:"); found {
+		match := sourceURLPathPattern.FindStringSubmatchIndex(url)
+		if match == nil {
+			return "", fmt.Errorf("can't find the path in source location '%s'", sourceLocation)
+		}
+		if repository != "" && RepositoryName(url[:match[0]]) != repository {
+			return "", fmt.Errorf("source location '%s' is in another repository than '%s'", sourceLocation, repository)
+		}
+		path = url[match[4]:match[5]]
+		isDirectory = url[match[2]:match[3]] == "tree"
+	} else if directory, found := strings.CutPrefix(sourceLocation, "dir:"); found {
+		path = filepath.ToSlash(filepath.Join(filepath.Dir(catalogLocation), directory))
+		if path == ".." || strings.HasPrefix(path, "../") || filepath.IsAbs(path) {
+			return "", fmt.Errorf("source location '%s' is outside of the repository", sourceLocation)
+		}
+	} else {
+		return "", fmt.Errorf("source location '%s' should start with 'url:' or 'dir:'", sourceLocation)
+	}
+
+	path = strings.Trim(path, "/")
+	if path == "" || path == "." {
+		return "*", nil
+	}
+	if isDirectory {
+		return "/" + path + "/", nil
+	}
+	return "/" + path, nil
+}
+
+// getOwnerName returns the CODEOWNERS owner for the owner of a component:
+// the team for groups, the login for users
+func getOwnerName(owner string, catalog Catalog) string {
+	ref := parseEntityRef(owner, "group")
+	if ref.Kind == "user" {
+		return "@" + catalog.userHandle(ref)
+	}
+	return "@" + ref.teamName()
+}
+
+// FormatRules returns CODEOWNERS rules for the Component entities in the
+// catalog that have an owner and a backstage.io/source-location annotation,
+// preceded by a comment, and the origin (the catalog file and the line the
+// component starts on) of each line. Source locations are either URLs
+// pointing to a directory or file in the repository on GitHub or GitLab, or
+// directories relative to the catalog file ('dir:'). It warns about source
+// locations it can't turn into a rule - like URLs of other repositories than
+// the repository, when that isn't empty.
+func FormatRules(catalog Catalog, repository string) (string, codeowners.Origins, codeowners.Anomalies) {
+	var rules []string
+	// the comment before the rules doesn't have an origin
+	origins := codeowners.Origins{{}}
+	var warnings codeowners.Anomalies
+
+	for _, entity := range catalog.Entities {
+		sourceLocation := entity.Metadata.Annotations[sourceLocationAnnotation]
+		if !strings.EqualFold(entity.Kind, "component") || entity.Spec.Owner == "" || sourceLocation == "" {
+			continue
+		}
+		pattern, error := getRulePattern(sourceLocation, entity.Location, repository)
+		if error != nil {
+			warnings = append(warnings, codeowners.Anomaly{
+				Reason: fmt.Sprintf("Component '%s': %s", entity.Metadata.Name, error.Error()),
+				Raw:    entity.Location,
+			})
+			continue
+		}
+		rules = append(rules, pattern+" "+getOwnerName(entity.Spec.Owner, catalog))
+		origins = append(origins, codeowners.Origin{File: entity.Location, LineNo: entity.Line})
+	}
+
+	if len(rules) == 0 {
+		return "", nil, warnings
+	}
+	return "# from the Backstage catalog\n" + strings.Join(rules, "\n") + "\n", origins, warnings
+}
@@ -0,0 +1,102 @@
+package backstage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func component(name string, owner string, sourceLocation string, location string) Entity {
+	return Entity{
+		Kind: "Component",
+		Metadata: Metadata{
+			Name:        name,
+			Annotations: map[string]string{"backstage.io/source-location": sourceLocation},
+		},
+		Spec:     Spec{Owner: owner},
+		Location: location,
+	}
+}
+
+func TestFormatRules(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("components with an owner and a source location become rules", func(t *testing.T) {
+		rules, origins, warnings := FormatRules(NewCatalog([]Entity{
+			component("sales", "group:default/sales", "url:https://github.com/cloud-heroes/shop/tree/main/libs/sales/", "catalog-info.yaml"),
+			component("refunds", "ch/refunds", "url:https://gitlab.com/cloud-heroes/shop/-/tree/main/libs/refunds", "catalog-info.yaml"),
+			component("readme", "user:jane", "url:https://github.com/cloud-heroes/shop/blob/main/README.md", "catalog-info.yaml"),
+			component("after-sales", "after-sales", "dir:.", "libs/after-sales/catalog-info.yaml"),
+			component("shop", "platform", "dir:.", "catalog-info.yaml"),
+			{Kind: "Component", Metadata: Metadata{Name: "ownerless"}},
+			{Kind: "User", Metadata: Metadata{Name: "jane", Annotations: map[string]string{"github.com/user-login": "jane-doe-ch"}}},
+		}), "")
+
+		assert.Nil(warnings)
+		assert.Equal(
+			"# from the Backstage catalog\n"+
+				"/libs/sales/ @sales\n"+
+				"/libs/refunds/ @ch/refunds\n"+
+				"/README.md @jane-doe-ch\n"+
+				"/libs/after-sales/ @after-sales\n"+
+				"* @platform\n",
+			rules,
+		)
+		assert.Len(origins, 6)
+		assert.Equal(codeowners.Origin{File: "libs/after-sales/catalog-info.yaml"}, origins[4])
+	})
+
+	t.Run("the origin of each rule is the component in the catalog file", func(t *testing.T) {
+		sales := component("sales", "sales", "dir:.", "libs/sales/catalog-info.yaml")
+		sales.Line = 12
+		_, origins, _ := FormatRules(NewCatalog([]Entity{sales}), "")
+
+		assert.Equal(codeowners.Origins{{}, {File: "libs/sales/catalog-info.yaml", LineNo: 12}}, origins)
+	})
+
+	t.Run("skips URLs of other repositories than the repository", func(t *testing.T) {
+		rules, _, warnings := FormatRules(NewCatalog([]Entity{
+			component("sales", "sales", "url:https://github.com/cloud-heroes/shop/tree/main/libs/sales/", "catalog-info.yaml"),
+			component("stock", "stock", "url:https://github.com/cloud-heroes/warehouse/tree/main/libs/stock/", "catalog-info.yaml"),
+		}), "github.com/cloud-heroes/shop")
+
+		assert.Equal("# from the Backstage catalog\n/libs/sales/ @sales\n", rules)
+		assert.Equal(codeowners.Anomalies{
+			{
+				Reason: "Component 'stock': source location 'url:https://github.com/cloud-heroes/warehouse/tree/main/libs/stock/' is in another repository than 'github.com/cloud-heroes/shop'",
+				Raw:    "catalog-info.yaml",
+			},
+		}, warnings)
+	})
+
+	t.Run("warns about source locations it can't turn into a rule", func(t *testing.T) {
+		rules, _, warnings := FormatRules(NewCatalog([]Entity{
+			component("elsewhere", "sales", "dir:../../other-repo", "catalog-info.yaml"),
+			component("bucket", "sales", "s3:my-bucket", "catalog-info.yaml"),
+			component("web", "sales", "url:https://example.com/sales", "catalog-info.yaml"),
+		}), "")
+
+		assert.Equal("", rules)
+		assert.Equal(codeowners.Anomalies{
+			{Reason: "Component 'elsewhere': source location 'dir:../../other-repo' is outside of the repository", Raw: "catalog-info.yaml"},
+			{Reason: "Component 'bucket': source location 's3:my-bucket' should start with 'url:' or 'dir:'", Raw: "catalog-info.yaml"},
+			{Reason: "Component 'web': can't find the path in source location 'url:https://example.com/sales'", Raw: "catalog-info.yaml"},
+		}, warnings)
+	})
+}
+
+func TestRepositoryName(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, url := range []string{
+		"https://github.com/Cloud-Heroes/shop",
+		"https://github.com/cloud-heroes/shop.git",
+		"git@github.com:cloud-heroes/shop.git",
+		"ssh://git@github.com:22/cloud-heroes/shop.git",
+	} {
+		assert.Equal("github.com/cloud-heroes/shop", RepositoryName(url), url)
+	}
+	assert.Equal("gitlab.com/cloud-heroes/platform/shop", RepositoryName("https://gitlab.com/cloud-heroes/platform/shop"))
+	assert.Equal("", RepositoryName(""))
+}
@@ -0,0 +1,44 @@
+package backstage
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// TeamSources returns the Group entities in the catalog as virtual teams,
+// one source per catalog file. Groups are named after their name, or
+// namespace/name when they're not in the default namespace. Their members
+// are listed by their login (see userHandle). Groups with a
+// github.com/team-slug annotation get that team as their handle.
+func TeamSources(catalog Catalog) []teams.Source {
+	var sources []teams.Source
+	sourceIndexes := map[string]int{}
+
+	for _, entity := range catalog.Entities {
+		if !strings.EqualFold(entity.Kind, "group") {
+			continue
+		}
+		index, found := sourceIndexes[entity.Location]
+		if !found {
+			index = len(sources)
+			sourceIndexes[entity.Location] = index
+			sources = append(sources, teams.Source{Name: entity.Location, Map: teams.Map{}, Settings: teams.Settings{}})
+		}
+		team := entity.ref().teamName()
+
+		members := []string{}
+		for _, member := range entity.Spec.Members {
+			handle := catalog.userHandle(parseEntityRef(member, "user"))
+			if !slices.Contains(members, handle) {
+				members = append(members, handle)
+			}
+		}
+		sources[index].Map[team] = members
+		if slug := entity.Metadata.Annotations["github.com/team-slug"]; slug != "" {
+			sources[index].Settings[team] = teams.Team{Handle: "@" + strings.TrimPrefix(slug, "@")}
+		}
+	}
+	return sources
+}
@@ -0,0 +1,38 @@
+package backstage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+func TestTeamSources(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("groups become teams, with their members' logins", func(t *testing.T) {
+		catalog := NewCatalog([]Entity{
+			{Kind: "Group", Metadata: Metadata{Name: "sales"}, Spec: Spec{Members: []string{"jane", "user:default/karl", "karl"}}, Location: "teams.yaml"},
+			{Kind: "Group", Metadata: Metadata{Name: "ux", Namespace: "ch", Annotations: map[string]string{"github.com/team-slug": "cloud-heroes/ux"}}, Location: "teams.yaml"},
+			{Kind: "User", Metadata: Metadata{Name: "jane", Annotations: map[string]string{"github.com/user-login": "jane-doe-ch"}}, Location: "people.yaml"},
+			{Kind: "Group", Metadata: Metadata{Name: "refunds"}, Spec: Spec{Members: []string{"Jane"}}, Location: "libs/refunds/catalog-info.yaml"},
+		})
+
+		assert.Equal([]teams.Source{
+			{
+				Name:     "teams.yaml",
+				Map:      teams.Map{"sales": {"jane-doe-ch", "karl"}, "ch/ux": {}},
+				Settings: teams.Settings{"ch/ux": {Handle: "@cloud-heroes/ux"}},
+			},
+			{
+				Name:     "libs/refunds/catalog-info.yaml",
+				Map:      teams.Map{"refunds": {"jane-doe-ch"}},
+				Settings: teams.Settings{},
+			},
+		}, TeamSources(catalog))
+	})
+
+	t.Run("no groups, no sources", func(t *testing.T) {
+		assert.Nil(TeamSources(NewCatalog([]Entity{{Kind: "Component", Metadata: Metadata{Name: "app"}}})))
+	})
+}
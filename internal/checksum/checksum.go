@@ -0,0 +1,105 @@
+package checksum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strings"
+)
+
+var stampPattern = regexp.MustCompile(`^# vcodeowners-checksum sources=([0-9a-f]{64}) output=([0-9a-f]{64})(?: from=([0-9]{4}-[0-9]{2}-[0-9]{2}))?(?: until=([0-9]{4}-[0-9]{2}-[0-9]{2}))?$`)
+
+// ErrNoChecksum means the content has no checksum line
+var ErrNoChecksum = errors.New("has no checksum")
+
+// ErrEdited means the content changed after it was generated
+var ErrEdited = errors.New("was edited after it was generated")
+
+// ErrOutdated means the content was generated from other sources than the
+// current ones
+var ErrOutdated = errors.New("was generated from other sources or options than the current ones")
+
+// ErrOtherMemberships means the content was generated with the team
+// memberships of another day, which changed since (or haven't started yet)
+var ErrOtherMemberships = errors.New("was generated with the team memberships of another day")
+
+// Stamp holds the checksums in the first line of a generated file, and the
+// period (YYYY-MM-DD, until is exclusive) in which the team memberships it
+// was generated with apply. An empty From or Until means the period is open
+// on that side.
+type Stamp struct {
+	Sources string
+	Output  string
+	From    string
+	Until   string
+}
+
+func write(hasher hash.Hash, content string) {
+	// the length goes first so "ab", "c" and "a", "bc" don't sum the same
+	fmt.Fprintf(hasher, "%d\n%s", len(content), content)
+}
+
+// Sum returns the (hex encoded) SHA-256 checksum of the contents, in order
+func Sum(contents ...string) string {
+	hasher := sha256.New()
+	for _, content := range contents {
+		write(hasher, content)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// Add returns the content with a first line that holds the checksum of the
+// sources it was generated from (the Sources of the stamp), the checksum of
+// the content itself and the period its team memberships apply in
+func Add(content string, stamp Stamp) string {
+	line := fmt.Sprintf("# vcodeowners-checksum sources=%s output=%s", stamp.Sources, Sum(content))
+	if stamp.From != "" {
+		line = line + " from=" + stamp.From
+	}
+	if stamp.Until != "" {
+		line = line + " until=" + stamp.Until
+	}
+	return line + "\n" + content
+}
+
+// Split returns the checksums in the first line of the content and the
+// content without that line. found is false when there's no checksum line.
+func Split(content string) (stamp Stamp, rest string, found bool) {
+	firstLine, rest, _ := strings.Cut(content, "\n")
+	matches := stampPattern.FindStringSubmatch(strings.TrimSuffix(firstLine, "\r"))
+	if matches == nil {
+		return Stamp{}, content, false
+	}
+	return Stamp{Sources: matches[1], Output: matches[2], From: matches[3], Until: matches[4]}, rest, true
+}
+
+// Strip returns the content without its checksum line
+func Strip(content string) string {
+	_, rest, _ := Split(content)
+	return rest
+}
+
+// Verify checks the content wasn't edited after it was generated and, when
+// sourcesSum isn't empty, that it was generated from the sources with that
+// checksum. When day (YYYY-MM-DD) isn't empty it also checks the team
+// memberships it was generated with apply on that day. It returns
+// ErrNoChecksum, ErrEdited, ErrOutdated or ErrOtherMemberships when not.
+func Verify(content string, sourcesSum string, day string) error {
+	stamp, rest, found := Split(content)
+	if !found {
+		return ErrNoChecksum
+	}
+	if Sum(rest) != stamp.Output {
+		return ErrEdited
+	}
+	if sourcesSum != "" && sourcesSum != stamp.Sources {
+		return ErrOutdated
+	}
+	if day != "" && ((stamp.From != "" && day < stamp.From) || (stamp.Until != "" && day >= stamp.Until)) {
+		return ErrOtherMemberships
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package checksum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSum(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(Sum("ab", "c"), Sum("ab", "c"))
+	assert.NotEqual(Sum("ab", "c"), Sum("a", "bc"))
+	assert.NotEqual(Sum("ab"), Sum("ab", ""))
+	assert.Len(Sum(), 64)
+}
+
+func TestVerify(t *testing.T) {
+	sourcesSum := Sum("* @ch/sales\n", "{\"ch/sales\": [\"jane\"]}")
+	stamped := Add("# generated\n* @jane\n", Stamp{Sources: sourcesSum})
+
+	t.Run("stamp goes on the first line", func(t *testing.T) {
+		assert := assert.New(t)
+		stamp, rest, found := Split(stamped)
+
+		assert.True(found)
+		assert.Equal(Stamp{Sources: sourcesSum, Output: Sum("# generated\n* @jane\n")}, stamp)
+		assert.Equal("# generated\n* @jane\n", rest)
+		assert.Equal("# generated\n* @jane\n", Strip(stamped))
+		assert.Equal("* @jane\n", Strip("* @jane\n"))
+	})
+
+	t.Run("unchanged content from the current sources", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Nil(Verify(stamped, sourcesSum, "2026-10-19"))
+		assert.Nil(Verify(stamped, "", ""))
+	})
+
+	t.Run("content without a checksum", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("* @jane\n", sourcesSum, ""), ErrNoChecksum)
+	})
+
+	t.Run("content edited after it was generated", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(stamped+"* @karl\n", sourcesSum, ""), ErrEdited)
+	})
+
+	t.Run("content generated from other sources", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(stamped, Sum("* @ch/sales\n"), ""), ErrOutdated)
+	})
+
+	t.Run("content generated with the team memberships of another day", func(t *testing.T) {
+		assert := assert.New(t)
+		stampedWithPeriod := Add("* @jane\n", Stamp{Sources: sourcesSum, From: "2026-03-01", Until: "2026-04-01"})
+		stamp, _, _ := Split(stampedWithPeriod)
+
+		assert.Equal("2026-03-01", stamp.From)
+		assert.Equal("2026-04-01", stamp.Until)
+		assert.Nil(Verify(stampedWithPeriod, sourcesSum, "2026-03-01"))
+		assert.Nil(Verify(stampedWithPeriod, sourcesSum, "2026-03-31"))
+		assert.ErrorIs(Verify(stampedWithPeriod, sourcesSum, "2026-02-28"), ErrOtherMemberships)
+		assert.ErrorIs(Verify(stampedWithPeriod, sourcesSum, "2026-04-01"), ErrOtherMemberships)
+	})
+}
@@ -2,24 +2,38 @@ package codeowners
 
 import "fmt"
 
-// Anomaly represents a problem in a CODEOWNERS file
+// Anomaly represents a problem in a CODEOWNERS file. Problems that aren't
+// tied to a line (e.g. in a team map) have LineNo 0. Problems in a file
+// included with `#!include` have that file's name in File.
 type Anomaly struct {
+	File   string `json:"file,omitempty"`
 	LineNo int    `json:"lineNo"`
 	Reason string `json:"reason"`
 	Raw    string `json:"raw"`
 }
 
 func (anomaly Anomaly) String() string {
+	if anomaly.LineNo == 0 {
+		return fmt.Sprintf("%s: \"%s\"", anomaly.Reason, anomaly.Raw)
+	}
+	if anomaly.File != "" {
+		return fmt.Sprintf("%s:%d, %s: \"%s\"", anomaly.File, anomaly.LineNo, anomaly.Reason, anomaly.Raw)
+	}
 	return fmt.Sprintf("Line %4d, %s: \"%s\"", anomaly.LineNo, anomaly.Reason, anomaly.Raw)
 }
 
 // Anomalies is a collection of problems in a CODEOWNERS file
 type Anomalies []Anomaly
 
-func (anomalies Anomalies) String() string {
-	var output string = "Syntax errors found in the input:\n"
+// Report returns the anomalies as a string, preceded by the passed heading
+func (anomalies Anomalies) Report(heading string) string {
+	var output string = heading + "\n"
 	for _, anomaly := range anomalies {
 		output += "  " + anomaly.String() + "\n"
 	}
 	return output
 }
+
+func (anomalies Anomalies) String() string {
+	return anomalies.Report("Syntax errors found in the input:")
+}
@@ -24,4 +24,36 @@ func TestString(t *testing.T) {
 		assert.Equal(expected, found)
 	})
 
+	t.Run("anomalies that aren't tied to a line", func(t *testing.T) {
+		var anomalies = Anomalies{
+			{
+				Reason: "Membership of team 'team1' ended on 2026-01-31",
+				Raw:    "user1",
+			},
+		}
+
+		expected := "Syntax errors found in the input:\n  Membership of team 'team1' ended on 2026-01-31: \"user1\"\n"
+		found := anomalies.String()
+
+		assert.Equal(expected, found)
+	})
+}
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("uses the passed heading", func(t *testing.T) {
+		var anomalies = Anomalies{
+			{
+				LineNo: 3,
+				Reason: "Some reason",
+				Raw:    "some line",
+			},
+		}
+
+		expected := "Some heading:\n  Line    3, Some reason: \"some line\"\n"
+		found := anomalies.Report("Some heading:")
+
+		assert.Equal(expected, found)
+	})
 }
@@ -0,0 +1,91 @@
+package codeowners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mightBeGroup returns true when the owner might stand for more than one
+// person: a (real) team like @org/team or a GitLab role like @@maintainers.
+// On GitLab top-level groups look like users (@group), so there any owner
+// might be a group unless it's among the users. We can't know how many
+// people are in those, so they could satisfy any number of required
+// approvals.
+func mightBeGroup(owner Owner, platform string, users KnownOwners) bool {
+	if owner.Type != "user-or-group" {
+		return false
+	}
+	if gitLabRolePattern.MatchString(owner.Name) || strings.Contains(owner.Name, "/") {
+		return true
+	}
+	return platform == "gitlab" && !users.Contains(owner)
+}
+
+// countDistinctOwners returns the number of distinct valid owners, and
+// whether that number is known (it isn't when one of the owners might be a
+// group)
+func countDistinctOwners(owners []Owner, platform string, users KnownOwners) (int, bool) {
+	var visited = map[string]bool{}
+
+	for _, owner := range owners {
+		if mightBeGroup(owner, platform, users) {
+			return 0, false
+		}
+		if owner.Type != "invalid" {
+			visited[owner.Name] = true
+		}
+	}
+	return len(visited), true
+}
+
+// CheckMinApprovers returns an anomaly for each section heading and rule
+// in a required section that requires more approvals than it has distinct
+// owners. Those can never be approved. The users are the owners known to be
+// one person (e.g. the members of the virtual teams); on the platform
+// "gitlab" other owners might be groups. Run it on the CST after the teams
+// have been applied; before that the owners are still (virtual) teams.
+func CheckMinApprovers(cst CST, platform string, users KnownOwners) Anomalies {
+	var anomalies Anomalies
+	var currentSection Line
+
+	for _, line := range cst {
+		if line.Type == "section-heading" {
+			currentSection = line
+		}
+		// optional sections never block merging
+		if currentSection.SectionMinApprovers <= 0 || currentSection.SectionOptional {
+			continue
+		}
+		if line.Type != "section-heading" && line.Type != "rule" {
+			continue
+		}
+
+		owners := line.Owners
+		// a rule without owners in a section inherits the owners of
+		// the section heading
+		if line.Type == "rule" && len(owners) == 0 {
+			owners = currentSection.Owners
+		}
+		// a section heading without owners only passes on the number of
+		// required approvals
+		if line.Type == "section-heading" && len(owners) == 0 {
+			continue
+		}
+
+		distinctOwners, known := countDistinctOwners(owners, platform, users)
+		if known && distinctOwners < currentSection.SectionMinApprovers {
+			anomalies = append(anomalies,
+				Anomaly{
+					File:   line.File,
+					LineNo: line.LineNo,
+					Reason: fmt.Sprintf(
+						"Section '%s' requires %d approvals, but there's only %d distinct owner(s)",
+						currentSection.SectionName, currentSection.SectionMinApprovers, distinctOwners,
+					),
+					Raw: line.Raw,
+				},
+			)
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,79 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMinApprovers(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("no sections, no anomalies", func(t *testing.T) {
+		cst, _ := Parse("* @user1\nsrc/ @user2")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "github", nil)))
+	})
+
+	t.Run("section without a minimum number of approvers", func(t *testing.T) {
+		cst, _ := Parse("[sales] @user1\nsrc/")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "github", nil)))
+	})
+
+	t.Run("section heading with enough distinct owners", func(t *testing.T) {
+		cst, _ := Parse("[sales][2] @user1 @user2\nsrc/")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "github", nil)))
+	})
+
+	t.Run("section heading with too few distinct owners & a rule inheriting them", func(t *testing.T) {
+		cst, _ := Parse("[sales][3] @user1 @user2 @user1\nsrc/")
+
+		assert.Equal(Anomalies{
+			{LineNo: 1, Reason: "Section 'sales' requires 3 approvals, but there's only 2 distinct owner(s)", Raw: "[sales][3] @user1 @user2 @user1"},
+			{LineNo: 2, Reason: "Section 'sales' requires 3 approvals, but there's only 2 distinct owner(s)", Raw: "src/"},
+		}, CheckMinApprovers(cst, "github", nil))
+	})
+
+	t.Run("rule in a section with too few owners of its own", func(t *testing.T) {
+		cst, _ := Parse("[sales][2]\nsrc/ @user1 @user2\ndocs/ @user3\n\n[other]\nlib/ @user4")
+
+		assert.Equal(Anomalies{
+			{LineNo: 3, Reason: "Section 'sales' requires 2 approvals, but there's only 1 distinct owner(s)", Raw: "docs/ @user3"},
+		}, CheckMinApprovers(cst, "github", nil))
+	})
+
+	t.Run("groups might have any number of members, so they're not reported", func(t *testing.T) {
+		cst, _ := Parse("[sales][3] @org/sales\nsrc/")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "github", nil)))
+	})
+
+	t.Run("GitLab roles might have any number of members, so they're not reported", func(t *testing.T) {
+		cst, _ := Parse("[sales][2] @@maintainers\n*.js")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "gitlab", nil)))
+	})
+
+	t.Run("on GitLab, owners that aren't known users might be top-level groups, so they're not reported", func(t *testing.T) {
+		cst, _ := Parse("[sales][2] @sales\n*.js")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "gitlab", nil)))
+	})
+
+	t.Run("on GitLab, owners that are known users are counted", func(t *testing.T) {
+		cst, _ := Parse("[sales][2] @Jane\n*.js")
+
+		assert.Equal(Anomalies{
+			{LineNo: 1, Reason: "Section 'sales' requires 2 approvals, but there's only 1 distinct owner(s)", Raw: "[sales][2] @Jane"},
+			{LineNo: 2, Reason: "Section 'sales' requires 2 approvals, but there's only 1 distinct owner(s)", Raw: "*.js"},
+		}, CheckMinApprovers(cst, "gitlab", NewKnownOwners([]string{"jane"})))
+	})
+
+	t.Run("optional sections never block merging, so they're not reported", func(t *testing.T) {
+		cst, _ := Parse("^[sales][3] @user1\nsrc/ @user2")
+
+		assert.Equal(0, len(CheckMinApprovers(cst, "github", nil)))
+	})
+}
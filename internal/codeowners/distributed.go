@@ -0,0 +1,46 @@
+package codeowners
+
+import (
+	"path"
+	"strings"
+)
+
+// prefixPattern returns the pattern of a rule in an owners file in the
+// directory as a pattern relative to the root of the repository. Like in
+// gitignore files, patterns with a slash at the start or in the middle are
+// relative to the directory, other patterns match at any depth below it.
+func prefixPattern(pattern string, directory string) string {
+	directory = strings.Trim(path.Clean(directory), "/")
+	if directory == "." || directory == "" {
+		return pattern
+	}
+	prefix := "/" + directory + "/"
+	switch {
+	case pattern == "*" || pattern == "**":
+		return prefix
+	case strings.HasPrefix(pattern, "/"):
+		return prefix + strings.TrimPrefix(pattern, "/")
+	case strings.Contains(strings.TrimSuffix(pattern, "/"), "/"):
+		return prefix + pattern
+	default:
+		return prefix + "**/" + pattern
+	}
+}
+
+// PrefixPatterns returns the lines of an owners file in the directory (with
+// forward slashes, relative to the root of the repository) with their
+// patterns relative to the root of the repository, and the file they came
+// from in File
+func PrefixPatterns(cst CST, directory string, fileName string) CST {
+	var returnValue CST
+	for _, line := range cst {
+		if line.File == "" {
+			line.File = fileName
+		}
+		if line.Type == "rule" {
+			line.RulePattern = prefixPattern(line.RulePattern, directory)
+		}
+		returnValue = append(returnValue, line)
+	}
+	return returnValue
+}
@@ -0,0 +1,40 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixPatterns(t *testing.T) {
+	t.Run("patterns become relative to the root of the repository", func(t *testing.T) {
+		assert := assert.New(t)
+		for pattern, expected := range map[string]string{
+			"*":         "/libs/sales/",
+			"**":        "/libs/sales/",
+			"/*":        "/libs/sales/*",
+			"/api/":     "/libs/sales/api/",
+			"api/v1/":   "/libs/sales/api/v1/",
+			"**/test/":  "/libs/sales/**/test/",
+			"*.js":      "/libs/sales/**/*.js",
+			"docs/":     "/libs/sales/**/docs/",
+			"README.md": "/libs/sales/**/README.md",
+			"src/*.go":  "/libs/sales/src/*.go",
+		} {
+			assert.Equal(expected, prefixPattern(pattern, "libs/sales"), pattern)
+		}
+		assert.Equal("*.js", prefixPattern("*.js", "."))
+		assert.Equal("/libs/sales/**/*.js", prefixPattern("*.js", "/libs/sales/"))
+	})
+
+	t.Run("lines keep their line number and get the file they came from", func(t *testing.T) {
+		assert := assert.New(t)
+		cst, _ := Parse("# sales\n*.js @ch/sales\n")
+
+		prefixed := PrefixPatterns(cst, "libs/sales", "libs/sales/VIRTUAL-CODEOWNERS.txt")
+
+		assert.Equal("/libs/sales/**/*.js @ch/sales\n", prefixed[1].String())
+		assert.Equal("libs/sales/VIRTUAL-CODEOWNERS.txt:2", prefixed[1].Location())
+		assert.Equal("# sales\n", prefixed[0].String())
+	})
+}
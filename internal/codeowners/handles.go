@@ -0,0 +1,98 @@
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitHub usernames: alphanumerics and single hyphens, not starting or ending
+// with a hyphen. Enterprise managed users have an '_shortcode' suffix.
+var gitHubUserPattern = regexp.MustCompile(`^[A-Za-z0-9]+(?:-[A-Za-z0-9]+)*(?:_[A-Za-z0-9]+)?$`)
+
+const gitHubUserMaxLength = 39
+
+// GitHub team slugs: alphanumerics, hyphens and underscores
+var gitHubTeamSlugPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// GitLab usernames and group paths: alphanumerics, '_', '-' and '.', not
+// starting with a '-' or '.' and not ending with a '.', '.git' or '.atom'
+var gitLabPathPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+const gitLabPathMaxLength = 255
+
+func checkGitHubHandle(handle string) error {
+	segments := strings.Split(strings.TrimPrefix(handle, "@"), "/")
+
+	if len(segments) > 2 {
+		return fmt.Errorf("teams on GitHub look like @org/team")
+	}
+	if len(segments[0]) > gitHubUserMaxLength {
+		return fmt.Errorf("user and organization names on GitHub are at most %d characters", gitHubUserMaxLength)
+	}
+	if !gitHubUserPattern.MatchString(segments[0]) {
+		return fmt.Errorf("user and organization names on GitHub only contain alphanumerics and single hyphens")
+	}
+	if len(segments) == 2 && !gitHubTeamSlugPattern.MatchString(segments[1]) {
+		return fmt.Errorf("team slugs on GitHub only contain alphanumerics, hyphens and underscores")
+	}
+	return nil
+}
+
+func checkGitLabHandle(handle string) error {
+	if gitLabRolePattern.MatchString(handle) {
+		return nil
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(handle, "@"), "/") {
+		if len(segment) > gitLabPathMaxLength {
+			return fmt.Errorf("user names and group paths on GitLab are at most %d characters", gitLabPathMaxLength)
+		}
+		if !gitLabPathPattern.MatchString(segment) ||
+			strings.HasSuffix(segment, ".") ||
+			strings.HasSuffix(segment, ".git") ||
+			strings.HasSuffix(segment, ".atom") {
+			return fmt.Errorf("user names and group paths on GitLab only contain alphanumerics, '_', '-' and '.' and don't end with '.', '.git' or '.atom'")
+		}
+	}
+	return nil
+}
+
+// CheckHandle returns an error when the owner's handle isn't valid on the
+// platform ("github" or "gitlab"). E-mail addresses and other owner types
+// are left alone.
+func CheckHandle(owner Owner, platform string) error {
+	if owner.Type != "user-or-group" {
+		return nil
+	}
+	if platform == "gitlab" {
+		return checkGitLabHandle(owner.Name)
+	}
+	if gitLabRolePattern.MatchString(owner.Name) {
+		return fmt.Errorf("roles like %s only exist on GitLab", owner.Name)
+	}
+	return checkGitHubHandle(owner.Name)
+}
+
+// CheckHandles returns an anomaly for each owner in the CST with a handle
+// that isn't valid on the platform. Run it on the CST after the teams have
+// been applied; before that the owners can still be virtual teams, which
+// don't need to follow the platform's rules.
+func CheckHandles(cst CST, platform string) Anomalies {
+	var anomalies Anomalies
+
+	for _, line := range cst {
+		for _, owner := range line.Owners {
+			if error := CheckHandle(owner, platform); error != nil {
+				anomalies = append(anomalies,
+					Anomaly{
+						File:   line.File,
+						LineNo: line.LineNo,
+						Reason: fmt.Sprintf("Invalid %s handle '%s' (%s)", platform, owner.Name, error.Error()),
+						Raw:    line.Raw,
+					},
+				)
+			}
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,53 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHandle(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("valid on GitHub", func(t *testing.T) {
+		for _, handle := range []string{"@john-doe", "@j", "@JohnDoe42", "@john-doe_acme", "@org/team", "@org/some_team-2", "@abcdefghijklmnopqrstuvwxyz0123456789abc"} {
+			assert.Nil(CheckHandle(ParseOwner(handle), "github"), handle)
+		}
+	})
+
+	t.Run("invalid on GitHub", func(t *testing.T) {
+		for _, handle := range []string{"@john--doe", "@john-", "@john.doe", "@john_doe_", "@org/team/subteam", "@org/team.x", "@@developer", "@abcdefghijklmnopqrstuvwxyz0123456789abcd"} {
+			assert.NotNil(CheckHandle(ParseOwner(handle), "github"), handle)
+		}
+	})
+
+	t.Run("valid on GitLab", func(t *testing.T) {
+		for _, handle := range []string{"@john_doe", "@john.doe", "@john-", "@group/subgroup/team", "@@developer", "@@maintainers"} {
+			assert.Nil(CheckHandle(ParseOwner(handle), "gitlab"), handle)
+		}
+	})
+
+	t.Run("invalid on GitLab", func(t *testing.T) {
+		for _, handle := range []string{"@john.git", "@group/project.atom"} {
+			assert.NotNil(CheckHandle(ParseOwner(handle), "gitlab"), handle)
+		}
+	})
+
+	t.Run("e-mail addresses are left alone", func(t *testing.T) {
+		assert.Nil(CheckHandle(ParseOwner("john.doe@example.com"), "github"))
+	})
+}
+
+func TestCheckHandles(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("reports handles that aren't valid on the platform", func(t *testing.T) {
+		cst, _ := Parse("* @john-doe @john.doe\nsrc/ @org/team/subteam")
+
+		assert.Equal(Anomalies{
+			{LineNo: 1, Reason: "Invalid github handle '@john.doe' (user and organization names on GitHub only contain alphanumerics and single hyphens)", Raw: "* @john-doe @john.doe"},
+			{LineNo: 2, Reason: "Invalid github handle '@org/team/subteam' (teams on GitHub look like @org/team)", Raw: "src/ @org/team/subteam"},
+		}, CheckHandles(cst, "github"))
+		assert.Equal(0, len(CheckHandles(cst, "gitlab")))
+	})
+}
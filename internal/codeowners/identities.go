@@ -0,0 +1,48 @@
+package codeowners
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Identities maps e-mail addresses to the handles their owners have on each
+// platform, e.g.
+//
+//	{
+//	  "jane@example.com": {"github": "jane-doe-ch", "gitlab": "jdoe"}
+//	}
+type Identities map[string]map[string]string
+
+// ParseIdentities parses an identities file in JSON format. E-mail addresses
+// are case insensitive, so they're stored in lower case.
+func ParseIdentities(identitiesString string) (Identities, error) {
+	var rawIdentities Identities
+	error := json.Unmarshal([]byte(identitiesString), &rawIdentities)
+	if error != nil {
+		return nil, error
+	}
+	identities := Identities{}
+
+	for email, handles := range rawIdentities {
+		if ParseOwner(email).Type != "e-mail" {
+			return nil, fmt.Errorf("identities should be keyed by e-mail address; '%s' isn't one", email)
+		}
+		identities[strings.ToLower(email)] = handles
+	}
+	return identities, nil
+}
+
+// Normalize returns the owner with its e-mail address replaced by its handle
+// on the platform. The boolean is false when the owner is an e-mail address
+// that has no handle on the platform.
+func (identities Identities) Normalize(owner Owner, platform string) (Owner, bool) {
+	if owner.Type != "e-mail" {
+		return owner, true
+	}
+	handle := identities[strings.ToLower(owner.Name)][platform]
+	if handle == "" {
+		return owner, false
+	}
+	return ParseOwner("@" + strings.TrimPrefix(handle, "@")), true
+}
@@ -0,0 +1,62 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIdentities(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("identities keyed by e-mail address, stored in lower case", func(t *testing.T) {
+		identities, error := ParseIdentities(`{"Jane@Example.com": {"github": "jane-doe", "gitlab": "jdoe"}}`)
+
+		assert.Nil(error)
+		assert.Equal(Identities{"jane@example.com": {"github": "jane-doe", "gitlab": "jdoe"}}, identities)
+	})
+
+	t.Run("error: not keyed by e-mail address", func(t *testing.T) {
+		_, error := ParseIdentities(`{"jane": {"github": "jane-doe"}}`)
+
+		assert.NotNil(error)
+		assert.Equal("identities should be keyed by e-mail address; 'jane' isn't one", error.Error())
+	})
+
+	t.Run("error: not the right shape", func(t *testing.T) {
+		_, error := ParseIdentities(`{"jane@example.com": "jane-doe"}`)
+
+		assert.NotNil(error)
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	assert := assert.New(t)
+	identities := Identities{"jane@example.com": {"github": "jane-doe", "gitlab": "@jdoe"}}
+
+	t.Run("replaces e-mail addresses with the handle on the platform", func(t *testing.T) {
+		owner, found := identities.Normalize(Owner{Type: "e-mail", Name: "JANE@example.com"}, "github")
+
+		assert.True(found)
+		assert.Equal(Owner{Type: "user-or-group", Name: "@jane-doe"}, owner)
+
+		owner, found = identities.Normalize(Owner{Type: "e-mail", Name: "jane@example.com"}, "gitlab")
+
+		assert.True(found)
+		assert.Equal(Owner{Type: "user-or-group", Name: "@jdoe"}, owner)
+	})
+
+	t.Run("leaves e-mail addresses without a handle on the platform alone", func(t *testing.T) {
+		owner, found := identities.Normalize(Owner{Type: "e-mail", Name: "karl@example.com"}, "github")
+
+		assert.False(found)
+		assert.Equal(Owner{Type: "e-mail", Name: "karl@example.com"}, owner)
+	})
+
+	t.Run("leaves other owners alone", func(t *testing.T) {
+		owner, found := identities.Normalize(Owner{Type: "user-or-group", Name: "@karl"}, "github")
+
+		assert.True(found)
+		assert.Equal(Owner{Type: "user-or-group", Name: "@karl"}, owner)
+	})
+}
@@ -0,0 +1,113 @@
+package codeowners
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var includePattern = regexp.MustCompile(`^#!include\s+(\S+)\s*$`)
+
+// Origin is the file and line number a line came from. The File of lines
+// from the file that does the including is empty.
+type Origin struct {
+	File   string
+	LineNo int
+}
+
+// Origins holds the origin of each line of content with its includes
+// expanded
+type Origins []Origin
+
+type includer struct {
+	readFile func(fileName string) (string, error)
+	lines    []string
+	origins  Origins
+}
+
+func (expander *includer) expand(content string, fileName string, chain []string) error {
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		origin := Origin{LineNo: lineNo}
+		if len(chain) > 1 {
+			origin.File = fileName
+		}
+		expander.lines = append(expander.lines, line)
+		expander.origins = append(expander.origins, origin)
+
+		matches := includePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		includedFileName := matches[1]
+		if !filepath.IsAbs(includedFileName) {
+			includedFileName = filepath.Join(filepath.Dir(fileName), includedFileName)
+		}
+		includedFileName = filepath.ToSlash(filepath.Clean(includedFileName))
+		if slices.Contains(chain, includedFileName) {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), includedFileName)
+		}
+		includedContent, readError := expander.readFile(includedFileName)
+		if readError != nil {
+			return fmt.Errorf("%s:%d: can't include '%s': %w", fileName, lineNo, matches[1], readError)
+		}
+		// the last line of a file that ends with a newline is empty; leave it
+		// out so the lines after the directive don't move down
+		includedContent = strings.TrimSuffix(includedContent, "\n")
+		if includeError := expander.expand(includedContent, includedFileName, append(slices.Clone(chain), includedFileName)); includeError != nil {
+			return includeError
+		}
+	}
+	return nil
+}
+
+// ExpandIncludes returns the content with the lines of the files its
+// `#!include path/to/fragment.txt` directives refer to after each directive,
+// and the origin of each line of the result. Paths are relative to the
+// directory of the file with the directive. Included files can include other
+// files; ExpandIncludes returns an error when that goes round in circles.
+func ExpandIncludes(content string, fileName string, readFile func(fileName string) (string, error)) (string, Origins, error) {
+	fileName = filepath.ToSlash(filepath.Clean(fileName))
+	expander := includer{readFile: readFile}
+	if expandError := expander.expand(content, fileName, []string{fileName}); expandError != nil {
+		return "", nil, expandError
+	}
+	return strings.Join(expander.lines, "\n"), expander.origins, nil
+}
+
+func (origins Origins) locate(lineNo int) Origin {
+	if lineNo < 1 || lineNo > len(origins) {
+		return Origin{LineNo: lineNo}
+	}
+	return origins[lineNo-1]
+}
+
+// Locate returns the lines and anomalies of parsed expanded content with
+// the file and line number they came from
+func (origins Origins) Locate(cst CST, anomalies Anomalies) (CST, Anomalies) {
+	var locatedLines CST
+	for _, line := range cst {
+		origin := origins.locate(line.LineNo)
+		line.File, line.LineNo = origin.File, origin.LineNo
+		locatedLines = append(locatedLines, line)
+	}
+	var locatedAnomalies Anomalies
+	for _, anomaly := range anomalies {
+		origin := origins.locate(anomaly.LineNo)
+		anomaly.File, anomaly.LineNo = origin.File, origin.LineNo
+		locatedAnomalies = append(locatedAnomalies, anomaly)
+	}
+	return locatedLines, locatedAnomalies
+}
+
+// HasIncludes returns true when the content has `#!include` directives
+func HasIncludes(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if includePattern.MatchString(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+	return false
+}
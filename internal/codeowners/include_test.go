@@ -0,0 +1,87 @@
+package codeowners
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFrom(files map[string]string) func(fileName string) (string, error) {
+	return func(fileName string) (string, error) {
+		content, found := files[fileName]
+		if !found {
+			return "", os.ErrNotExist
+		}
+		return content, nil
+	}
+}
+
+func TestExpandIncludes(t *testing.T) {
+	t.Run("without includes the content stays as it is", func(t *testing.T) {
+		assert := assert.New(t)
+		expanded, origins, error := ExpandIncludes("* @jane\n", ".github/VIRTUAL-CODEOWNERS.txt", readFrom(nil))
+
+		assert.Nil(error)
+		assert.Equal("* @jane\n", expanded)
+		assert.Equal(Origins{{LineNo: 1}, {LineNo: 2}}, origins)
+		assert.False(HasIncludes("* @jane\n#! include is not a directive\n"))
+	})
+
+	t.Run("includes, relative to the including file and nested", func(t *testing.T) {
+		assert := assert.New(t)
+		files := map[string]string{
+			".github/domains/sales.txt":       "libs/sales/ @ch/sales\n#!include after-sales.txt\n",
+			".github/domains/after-sales.txt": "libs/after-sales/ @ch/after-sales\n",
+		}
+		expanded, origins, error := ExpandIncludes("* @jane\n  #!include domains/sales.txt\ndocs/ @davy\n", ".github/VIRTUAL-CODEOWNERS.txt", readFrom(files))
+
+		assert.Nil(error)
+		assert.True(HasIncludes("* @jane\n  #!include domains/sales.txt\n"))
+		assert.Equal(
+			"* @jane\n  #!include domains/sales.txt\nlibs/sales/ @ch/sales\n#!include after-sales.txt\nlibs/after-sales/ @ch/after-sales\ndocs/ @davy\n",
+			expanded,
+		)
+		assert.Equal(Origins{
+			{LineNo: 1},
+			{LineNo: 2},
+			{File: ".github/domains/sales.txt", LineNo: 1},
+			{File: ".github/domains/sales.txt", LineNo: 2},
+			{File: ".github/domains/after-sales.txt", LineNo: 1},
+			{LineNo: 3},
+			{LineNo: 4},
+		}, origins)
+	})
+
+	t.Run("lines and anomalies keep the file and line they came from", func(t *testing.T) {
+		assert := assert.New(t)
+		files := map[string]string{"sales.txt": "libs/sales/ @ch/sales\nlibs/oops/ jane\n"}
+		expanded, origins, _ := ExpandIncludes("#!include sales.txt\n* @jane\n", "VIRTUAL-CODEOWNERS.txt", readFrom(files))
+
+		lines, anomalies := origins.Locate(Parse(expanded))
+
+		assert.Equal("sales.txt:2", lines[2].Location())
+		assert.Equal("line 2", lines[3].Location())
+		assert.Equal(Anomalies{{File: "sales.txt", LineNo: 2, Reason: "Invalid user 'jane'", Raw: "libs/oops/ jane"}}, anomalies)
+		assert.Equal("sales.txt:2, Invalid user 'jane': \"libs/oops/ jane\"", anomalies[0].String())
+	})
+
+	t.Run("error: include cycle", func(t *testing.T) {
+		files := map[string]string{
+			"a.txt": "#!include b.txt\n",
+			"b.txt": "#!include ./a.txt\n",
+		}
+		_, _, error := ExpandIncludes("#!include a.txt\n", "VIRTUAL-CODEOWNERS.txt", readFrom(files))
+
+		assert.NotNil(t, error)
+		assert.Equal(t, "include cycle: VIRTUAL-CODEOWNERS.txt -> a.txt -> b.txt -> a.txt", error.Error())
+	})
+
+	t.Run("error: file that can't be read", func(t *testing.T) {
+		_, _, error := ExpandIncludes("* @jane\n#!include missing.txt\n", "VIRTUAL-CODEOWNERS.txt", readFrom(nil))
+
+		assert.NotNil(t, error)
+		assert.Equal(t, fmt.Sprintf("VIRTUAL-CODEOWNERS.txt:2: can't include 'missing.txt': %s", os.ErrNotExist), error.Error())
+	})
+}
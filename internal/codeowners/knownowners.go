@@ -0,0 +1,193 @@
+package codeowners
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KnownOwners is the set of users, teams and e-mail addresses that exist in
+// the organization. Names are case insensitive, so they're stored in lower
+// case, without their '@'.
+type KnownOwners map[string]bool
+
+// knownOwner holds the fields of the member and team objects the GitHub and
+// GitLab APIs return that identify them
+type knownOwner struct {
+	// GitHub users
+	Login string `json:"login"`
+	// GitHub teams
+	Slug         string `json:"slug"`
+	HTMLURL      string `json:"html_url"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+	// GitLab users & groups
+	Username string `json:"username"`
+	FullPath string `json:"full_path"`
+	// both
+	Email       string `json:"email"`
+	PublicEmail string `json:"public_email"`
+}
+
+var gitHubTeamURLPattern = regexp.MustCompile(`/orgs/([^/]+)/teams/[^/]+$`)
+
+// GitHubTeamOrganization returns the organization a GitHub team belongs to:
+// the login of its organization, or else the one in its html_url. It returns
+// "" when neither tells.
+func GitHubTeamOrganization(organizationLogin string, htmlURL string) string {
+	if organizationLogin != "" {
+		return organizationLogin
+	}
+	if match := gitHubTeamURLPattern.FindStringSubmatch(htmlURL); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func (owner knownOwner) names() []string {
+	var returnValue []string
+
+	if owner.Slug != "" {
+		if organization := GitHubTeamOrganization(owner.Organization.Login, owner.HTMLURL); organization != "" {
+			returnValue = append(returnValue, organization+"/"+owner.Slug)
+		}
+	}
+	for _, name := range []string{owner.Login, owner.Username, owner.FullPath, owner.Email, owner.PublicEmail} {
+		if name != "" {
+			returnValue = append(returnValue, name)
+		}
+	}
+	return returnValue
+}
+
+func (knownOwners KnownOwners) add(name string) {
+	knownOwners[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))] = true
+}
+
+// addJSON adds the owners in a JSON value: a name, an object the GitHub or
+// GitLab API returns for a member or team, or an array or object holding
+// those (e.g. {"members": [...], "teams": [...]}).
+func (knownOwners KnownOwners) addJSON(raw json.RawMessage) error {
+	trimmed := strings.TrimSpace(string(raw))
+
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+		var values []json.RawMessage
+		if error := json.Unmarshal(raw, &values); error != nil {
+			return error
+		}
+		for _, value := range values {
+			if error := knownOwners.addJSON(value); error != nil {
+				return error
+			}
+		}
+	case strings.HasPrefix(trimmed, "{"):
+		var owner knownOwner
+		if error := json.Unmarshal(raw, &owner); error == nil && len(owner.names()) > 0 {
+			for _, name := range owner.names() {
+				knownOwners.add(name)
+			}
+			return nil
+		}
+		var values map[string]json.RawMessage
+		if error := json.Unmarshal(raw, &values); error != nil {
+			return error
+		}
+		for _, value := range values {
+			if error := knownOwners.addJSON(value); error != nil {
+				return error
+			}
+		}
+	case strings.HasPrefix(trimmed, "\""):
+		var name string
+		if error := json.Unmarshal(raw, &name); error != nil {
+			return error
+		}
+		knownOwners.add(name)
+	default:
+		return fmt.Errorf("unexpected value in known owners: %s", trimmed)
+	}
+	return nil
+}
+
+// ParseKnownOwners parses a list of known owners. That's either a plain
+// list with one user, team or e-mail address per line (# starts a comment),
+// or a JSON export of the members and teams of an organization as the
+// GitHub or GitLab APIs return them, e.g.
+//
+//	[
+//	  {"login": "jane-doe-ch"},
+//	  {"slug": "sales", "organization": {"login": "cloud-heroes"}},
+//	  {"username": "jdoe"},
+//	  {"full_path": "cloud-heroes/after-sales"}
+//	]
+func ParseKnownOwners(knownOwnersString string) (KnownOwners, error) {
+	knownOwners := KnownOwners{}
+	trimmed := strings.TrimSpace(knownOwnersString)
+
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		if error := knownOwners.addJSON(json.RawMessage(trimmed)); error != nil {
+			return nil, error
+		}
+		return knownOwners, nil
+	}
+
+	for _, line := range strings.Split(knownOwnersString, "\n") {
+		name, _, _ := strings.Cut(line, "#")
+		if strings.TrimSpace(name) != "" {
+			knownOwners.add(name)
+		}
+	}
+	return knownOwners, nil
+}
+
+// NewKnownOwners returns the known owners with the names
+func NewKnownOwners(names []string) KnownOwners {
+	knownOwners := KnownOwners{}
+	for _, name := range names {
+		knownOwners.add(name)
+	}
+	return knownOwners
+}
+
+// Contains returns true when the owner is a known one. GitLab's role
+// shorthands (e.g. @@maintainers) always are.
+func (knownOwners KnownOwners) Contains(owner Owner) bool {
+	if strings.HasPrefix(owner.Name, "@@") {
+		return true
+	}
+	return knownOwners[strings.ToLower(strings.TrimPrefix(owner.Name, "@"))]
+}
+
+// CheckKnownOwners returns an anomaly for each owner in the CST that isn't
+// among the known owners, on the first line it occurs. Run it on the CST
+// after the teams have been applied; before that the owners are still
+// (virtual) teams.
+func CheckKnownOwners(cst CST, knownOwners KnownOwners) Anomalies {
+	var anomalies Anomalies
+	var reported = map[string]bool{}
+
+	for _, line := range cst {
+		if line.Type != "section-heading" && line.Type != "rule" {
+			continue
+		}
+		for _, owner := range line.Owners {
+			if owner.Type != "user-or-group" && owner.Type != "e-mail" {
+				continue
+			}
+			if knownOwners.Contains(owner) || reported[strings.ToLower(owner.Name)] {
+				continue
+			}
+			reported[strings.ToLower(owner.Name)] = true
+			anomalies = append(anomalies, Anomaly{
+				File:   line.File,
+				LineNo: line.LineNo,
+				Reason: fmt.Sprintf("Unknown owner '%s'", owner.Name),
+				Raw:    line.Raw,
+			})
+		}
+	}
+	return anomalies
+}
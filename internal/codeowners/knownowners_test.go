@@ -0,0 +1,98 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKnownOwners(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("plain list, with or without '@', with comments", func(t *testing.T) {
+		knownOwners, error := ParseKnownOwners("# people\n@Jane-Doe\nkarl # the other one\n\ncloud-heroes/sales\njan@example.com\n")
+
+		assert.Nil(error)
+		assert.Equal(KnownOwners{
+			"jane-doe":           true,
+			"karl":               true,
+			"cloud-heroes/sales": true,
+			"jan@example.com":    true,
+		}, knownOwners)
+	})
+
+	t.Run("GitHub API export of members and teams", func(t *testing.T) {
+		knownOwners, error := ParseKnownOwners(`{
+			"members": [{"login": "jane-doe", "id": 1}, {"login": "karl", "id": 2}],
+			"teams": [
+				{"slug": "sales", "organization": {"login": "cloud-heroes"}},
+				{"slug": "after-sales", "html_url": "https://github.com/orgs/cloud-heroes/teams/after-sales"}
+			]
+		}`)
+
+		assert.Nil(error)
+		assert.Equal(KnownOwners{
+			"jane-doe":                 true,
+			"karl":                     true,
+			"cloud-heroes/sales":       true,
+			"cloud-heroes/after-sales": true,
+		}, knownOwners)
+	})
+
+	t.Run("GitLab API export of members and groups", func(t *testing.T) {
+		knownOwners, error := ParseKnownOwners(`[
+			{"username": "jdoe", "public_email": "jane@example.com"},
+			{"full_path": "cloud-heroes/sales/emea"}
+		]`)
+
+		assert.Nil(error)
+		assert.Equal(KnownOwners{
+			"jdoe":                    true,
+			"jane@example.com":        true,
+			"cloud-heroes/sales/emea": true,
+		}, knownOwners)
+	})
+
+	t.Run("plain JSON array of names", func(t *testing.T) {
+		knownOwners, error := ParseKnownOwners(`["jane-doe", "@karl"]`)
+
+		assert.Nil(error)
+		assert.Equal(KnownOwners{"jane-doe": true, "karl": true}, knownOwners)
+	})
+
+	t.Run("error: invalid JSON", func(t *testing.T) {
+		_, error := ParseKnownOwners(`[{"login": "jane-doe"`)
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: unexpected values", func(t *testing.T) {
+		_, error := ParseKnownOwners(`[42]`)
+
+		assert.NotNil(error)
+		assert.Equal("unexpected value in known owners: 42", error.Error())
+	})
+}
+
+func TestCheckKnownOwners(t *testing.T) {
+	assert := assert.New(t)
+	knownOwners := KnownOwners{"jane-doe": true, "cloud-heroes/sales": true, "jan@example.com": true}
+
+	t.Run("no anomalies when all owners are known, regardless of case", func(t *testing.T) {
+		cst, _ := Parse("* @Jane-Doe @cloud-heroes/sales jan@example.com\n[Sales] @@maintainers\n")
+
+		assert.Nil(CheckKnownOwners(cst, knownOwners))
+	})
+
+	t.Run("reports unknown owners once, on the first line they occur", func(t *testing.T) {
+		cst, _ := Parse("# comment\n* @jane-doe @karl\nlibs/ @karl piet@example.com\n")
+
+		assert.Equal(
+			Anomalies{
+				{LineNo: 2, Reason: "Unknown owner '@karl'", Raw: "* @jane-doe @karl"},
+				{LineNo: 3, Reason: "Unknown owner 'piet@example.com'", Raw: "libs/ @karl piet@example.com"},
+			},
+			CheckKnownOwners(cst, knownOwners),
+		)
+	})
+}
@@ -0,0 +1,195 @@
+package codeowners
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PatternChange lists who gained and who lost ownership of a rule's pattern
+type PatternChange struct {
+	Section string
+	Pattern string
+	Gained  []string
+	Lost    []string
+}
+
+// OwnerChange lists the patterns an owner gained and lost ownership of
+type OwnerChange struct {
+	Owner  string
+	Gained []string
+	Lost   []string
+}
+
+// OwnershipChanges are the changes in ownership between two CODEOWNERS,
+// per pattern and per owner
+type OwnershipChanges struct {
+	Patterns []PatternChange
+	Owners   []OwnerChange
+}
+
+type sectionPattern struct {
+	section string
+	pattern string
+}
+
+// patternName returns the pattern, prefixed with its section when it has one
+func patternName(section string, pattern string) string {
+	if section == "" {
+		return pattern
+	}
+	return "[" + section + "] " + pattern
+}
+
+// getOwnership returns the owners of each pattern. When a pattern occurs more
+// than once in a section the last one wins, like it does on GitHub and
+// GitLab.
+func getOwnership(cst CST) (map[sectionPattern][]string, []sectionPattern) {
+	ownership := map[sectionPattern][]string{}
+	var order []sectionPattern
+	for _, line := range cst {
+		if line.Type != "rule" {
+			continue
+		}
+		name := sectionPattern{section: line.RuleSection, pattern: line.RulePattern}
+		if _, found := ownership[name]; !found {
+			order = append(order, name)
+		}
+		var owners []string
+		for _, owner := range line.Owners {
+			if (owner.Type == "user-or-group" || owner.Type == "e-mail") && !slices.Contains(owners, owner.Name) {
+				owners = append(owners, owner.Name)
+			}
+		}
+		ownership[name] = owners
+	}
+	return ownership, order
+}
+
+// Difference returns the items of left that aren't in right, in the order
+// they're in left
+func Difference(left []string, right []string) []string {
+	var returnValue []string
+	for _, item := range left {
+		if !slices.Contains(right, item) {
+			returnValue = append(returnValue, item)
+		}
+	}
+	return returnValue
+}
+
+// DiffOwnership returns who gained and lost ownership of which patterns
+// between the before and after CODEOWNERS. Patterns are in the order they
+// appear in (first before, then the ones only in after), owners are sorted
+// by name.
+func DiffOwnership(before CST, after CST) OwnershipChanges {
+	beforeOwnership, beforeOrder := getOwnership(before)
+	afterOwnership, afterOrder := getOwnership(after)
+	order := beforeOrder
+	for _, name := range afterOrder {
+		if !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+
+	var changes OwnershipChanges
+	gainedByOwner := map[string][]string{}
+	lostByOwner := map[string][]string{}
+	for _, name := range order {
+		gained := Difference(afterOwnership[name], beforeOwnership[name])
+		lost := Difference(beforeOwnership[name], afterOwnership[name])
+		if len(gained) == 0 && len(lost) == 0 {
+			continue
+		}
+		slices.Sort(gained)
+		slices.Sort(lost)
+		changes.Patterns = append(changes.Patterns, PatternChange{Section: name.section, Pattern: name.pattern, Gained: gained, Lost: lost})
+		for _, owner := range gained {
+			gainedByOwner[owner] = append(gainedByOwner[owner], patternName(name.section, name.pattern))
+		}
+		for _, owner := range lost {
+			lostByOwner[owner] = append(lostByOwner[owner], patternName(name.section, name.pattern))
+		}
+	}
+
+	owners := slices.Sorted(maps.Keys(gainedByOwner))
+	for owner := range lostByOwner {
+		if !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+	slices.Sort(owners)
+	for _, owner := range owners {
+		changes.Owners = append(changes.Owners, OwnerChange{Owner: owner, Gained: gainedByOwner[owner], Lost: lostByOwner[owner]})
+	}
+	return changes
+}
+
+func (changes OwnershipChanges) String() string {
+	if len(changes.Patterns) == 0 {
+		return "No ownership changes\n"
+	}
+	var returnValue strings.Builder
+
+	returnValue.WriteString("Per pattern:\n")
+	for _, change := range changes.Patterns {
+		returnValue.WriteString(patternName(change.Section, change.Pattern) + "\n")
+		for _, owner := range change.Gained {
+			returnValue.WriteString("  + " + owner + "\n")
+		}
+		for _, owner := range change.Lost {
+			returnValue.WriteString("  - " + owner + "\n")
+		}
+	}
+	returnValue.WriteString("\nPer owner:\n")
+	for _, change := range changes.Owners {
+		returnValue.WriteString(change.Owner + "\n")
+		for _, pattern := range change.Gained {
+			returnValue.WriteString("  + " + pattern + "\n")
+		}
+		for _, pattern := range change.Lost {
+			returnValue.WriteString("  - " + pattern + "\n")
+		}
+	}
+	return returnValue.String()
+}
+
+// markdownCode returns the text as inline code, escaping the | so it doesn't
+// end the table cell
+func markdownCode(text string) string {
+	return "`" + strings.ReplaceAll(text, "|", `\|`) + "`"
+}
+
+func markdownCodeList(items []string) string {
+	var formatted []string
+	for _, item := range items {
+		formatted = append(formatted, markdownCode(item))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// Markdown returns the changes as markdown tables, e.g. for a pull request
+// description. Owners are in code spans, so pasting it doesn't mention (and
+// notify) them.
+func (changes OwnershipChanges) Markdown() string {
+	if len(changes.Patterns) == 0 {
+		return "No ownership changes.\n"
+	}
+	var returnValue strings.Builder
+
+	returnValue.WriteString("#### Ownership changes per pattern\n\n")
+	returnValue.WriteString("| Pattern | Gained | Lost |\n| --- | --- | --- |\n")
+	for _, change := range changes.Patterns {
+		returnValue.WriteString("| " + markdownCode(patternName(change.Section, change.Pattern)) +
+			" | " + markdownCodeList(change.Gained) +
+			" | " + markdownCodeList(change.Lost) + " |\n")
+	}
+	returnValue.WriteString("\n#### Ownership changes per owner\n\n")
+	returnValue.WriteString("| Owner | Gained | Lost |\n| --- | --- | --- |\n")
+	for _, change := range changes.Owners {
+		returnValue.WriteString("| " + markdownCode(change.Owner) +
+			" | " + markdownCodeList(change.Gained) +
+			" | " + markdownCodeList(change.Lost) + " |\n")
+	}
+	return returnValue.String()
+}
@@ -0,0 +1,69 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffOwnership(t *testing.T) {
+	before, _ := Parse("* @jane\nlibs/sales/ @jane @karl\nlibs/gone/ @karl\n[Docs]\ndocs/ @davy\n")
+	after, _ := Parse("* @jane\nlibs/sales/ @jane @gregory\nlibs/sales/ @jane @gregory @karl\n[Docs]\ndocs/ @jane\nlibs/new/ @gregory\n")
+
+	t.Run("no changes", func(t *testing.T) {
+		assert := assert.New(t)
+		changes := DiffOwnership(before, before)
+
+		assert.Nil(changes.Patterns)
+		assert.Equal("No ownership changes\n", changes.String())
+		assert.Equal("No ownership changes.\n", changes.Markdown())
+	})
+
+	t.Run("per pattern and per owner; the last occurrence of a pattern wins", func(t *testing.T) {
+		assert := assert.New(t)
+		changes := DiffOwnership(before, after)
+
+		assert.Equal([]PatternChange{
+			{Pattern: "libs/sales/", Gained: []string{"@gregory"}},
+			{Pattern: "libs/gone/", Lost: []string{"@karl"}},
+			{Section: "Docs", Pattern: "docs/", Gained: []string{"@jane"}, Lost: []string{"@davy"}},
+			{Section: "Docs", Pattern: "libs/new/", Gained: []string{"@gregory"}},
+		}, changes.Patterns)
+		assert.Equal([]OwnerChange{
+			{Owner: "@davy", Lost: []string{"[Docs] docs/"}},
+			{Owner: "@gregory", Gained: []string{"libs/sales/", "[Docs] libs/new/"}},
+			{Owner: "@jane", Gained: []string{"[Docs] docs/"}},
+			{Owner: "@karl", Lost: []string{"libs/gone/"}},
+		}, changes.Owners)
+		assert.Equal(
+			"Per pattern:\n"+
+				"libs/sales/\n  + @gregory\n"+
+				"libs/gone/\n  - @karl\n"+
+				"[Docs] docs/\n  + @jane\n  - @davy\n"+
+				"[Docs] libs/new/\n  + @gregory\n"+
+				"\nPer owner:\n"+
+				"@davy\n  - [Docs] docs/\n"+
+				"@gregory\n  + libs/sales/\n  + [Docs] libs/new/\n"+
+				"@jane\n  + [Docs] docs/\n"+
+				"@karl\n  - libs/gone/\n",
+			changes.String(),
+		)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		assert := assert.New(t)
+		before, _ := Parse("*.{js|ts} @jane\n")
+		after, _ := Parse("*.{js|ts} @karl\n")
+
+		assert.Equal(
+			"#### Ownership changes per pattern\n\n"+
+				"| Pattern | Gained | Lost |\n| --- | --- | --- |\n"+
+				"| `*.{js\\|ts}` | `@karl` | `@jane` |\n"+
+				"\n#### Ownership changes per owner\n\n"+
+				"| Owner | Gained | Lost |\n| --- | --- | --- |\n"+
+				"| `@jane` |  | `*.{js\\|ts}` |\n"+
+				"| `@karl` | `*.{js\\|ts}` |  |\n",
+			DiffOwnership(before, after).Markdown(),
+		)
+	})
+}
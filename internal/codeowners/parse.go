@@ -12,8 +12,12 @@ var ruleLinePattern = regexp.MustCompile(`^(?<filesPattern>[^\s]+)(?<spaces>\s+)
 var sectionLineWithoutOwnersPattern = regexp.MustCompile(`^(?<optionalIndicator>\^)?\[(?<name>[^\]]+)\](?:\[(?<minApprovers>[0-9]+)\])?(?:\s*)(?:#(?<comment>.*))?$`)
 var sectionLinePattern = regexp.MustCompile(`^(?<optionalIndicator>\^)?\[(?<name>[^\]]+)\](?:\[(?<minApprovers>[0-9]+)\])?(?<spaces>\s+)(?<userNames>[^#]+)(?:#(?<comment>.*))?$`)
 var ownerSeparatorPattern = regexp.MustCompile(`\s+`)
-var userOrGroupPattern = regexp.MustCompile("^@.+$")
-var emailPattern = regexp.MustCompile("^[^@]+@.+$")
+
+// a handle segment is valid on at least one of the platforms: letters, digits,
+// '_', '-' and '.', not starting with a '-' or '.' and not ending with a '.'
+var userOrGroupPattern = regexp.MustCompile(`^@[A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_-])?(?:/[A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_-])?)*(?::[A-Za-z0-9_-]+)?$`)
+var gitLabRolePattern = regexp.MustCompile(`^@@(?:developer|maintainer|owner)s?$`)
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s.]+(?:\.[^@\s.]+)+$`)
 
 // Line represents a line in a CODEOWNERS file
 type Line struct {
@@ -21,6 +25,9 @@ type Line struct {
 	Type   string `json:"type"`
 	LineNo int    `json:"lineNo"`
 	Raw    string `json:"raw"`
+	// the file the line came from, when it's from a file included with
+	// `#!include`
+	File string `json:"file,omitempty"`
 
 	// rule only
 	RulePattern string `json:"rulePattern"`
@@ -35,22 +42,63 @@ type Line struct {
 	Spaces        string  `json:"spaces"`
 	Owners        []Owner `json:"owners"`
 	InlineComment string  `json:"inlineComment"`
+
+	// which members of teams were selected for this line, for teams that
+	// only want some of their members listed per rule
+	Selections []Selection `json:"selections,omitempty"`
+}
+
+// Selection records which members of a team were listed on a line
+type Selection struct {
+	Team     string   `json:"team"`
+	Members  []string `json:"members"`
+	TeamSize int      `json:"teamSize"`
 }
 
 // CST represents the Concrete Syntax Tree of a CODEOWNERS file
 type CST []Line
 
+// Location returns where the line is, e.g. "line 12" or, for a line from an
+// included file, "fragments/sales.txt:12"
+func (line Line) Location() string {
+	if line.File != "" {
+		return fmt.Sprintf("%s:%d", line.File, line.LineNo)
+	}
+	return fmt.Sprintf("line %d", line.LineNo)
+}
+
 // Owner represents an owner in rule or section-heading
 //
 // Types:
 //   - "user-or-group" (these start with an "@" symbol e.g. @john_doe or @the_a_team)
+//     followed by a name that's valid on at least one platform (see
+//     CheckHandles for the rules of a specific platform).
+//     A user-or-group can refer to a role within a (virtual) team with a
+//     suffix, e.g. @the_a_team:maintainers
 //   - "e-mail" (e-mail addresses. We're not checking against the entire RFC 5322,
-//     just a simple check for presence of an "@" symbol)
+//     just for a local part, an "@" symbol and a domain with at least one dot)
+//   - "exclusion" (a user-or-group or e-mail prefixed with a "-", e.g.
+//     -@john_doe. Removes that owner from the (expanded) owners of the line)
 //   - "invalid" (anything else)
 type Owner struct {
-	// user-or-group, e-mail, invalid
+	// user-or-group, e-mail, exclusion, invalid
 	Type string `json:"type"`
 	Name string `json:"name"`
+	// the role suffix of a user-or-group, if any
+	Role string `json:"role,omitempty"`
+}
+
+// ExcludedName returns the name of the owner an exclusion excludes
+func (owner Owner) ExcludedName() string {
+	return strings.TrimPrefix(owner.Name, "-")
+}
+
+// NameWithoutRole returns the name of the owner without its role suffix
+func (owner Owner) NameWithoutRole() string {
+	if owner.Role == "" {
+		return owner.Name
+	}
+	return strings.TrimSuffix(owner.Name, ":"+owner.Role)
 }
 
 type parseState struct {
@@ -71,11 +119,36 @@ func getOptionalInt(fragment string) int {
 
 }
 
+// ParseOwner classifies an owner as a user-or-group, an e-mail address, an
+// exclusion or as invalid
 func ParseOwner(owner string) Owner {
+	if excludedOwner, found := strings.CutPrefix(owner, "-"); found {
+		if ParseOwner(excludedOwner).Type == "user-or-group" || ParseOwner(excludedOwner).Type == "e-mail" {
+			return Owner{
+				Type: "exclusion",
+				Name: owner,
+			}
+		}
+		return Owner{
+			Type: "invalid",
+			Name: owner,
+		}
+	}
+	if gitLabRolePattern.MatchString(owner) {
+		return Owner{
+			Type: "user-or-group",
+			Name: owner,
+		}
+	}
 	if userOrGroupPattern.MatchString(owner) {
+		var role string
+		if roleSeparatorPosition := strings.LastIndex(owner, ":"); roleSeparatorPosition > 1 {
+			role = owner[roleSeparatorPosition+1:]
+		}
 		return Owner{
 			Type: "user-or-group",
 			Name: owner,
+			Role: role,
 		}
 	}
 	if emailPattern.MatchString(owner) {
@@ -129,7 +202,7 @@ func parseSectionHeadLine(line string, lineNo int, state parseState) (Line, pars
 	currentSectionHasValidUsers := false
 
 	for _, owner := range owners {
-		if owner.Type != "invalid" {
+		if owner.Type != "invalid" && owner.Type != "exclusion" {
 			currentSectionHasValidUsers = true
 			break
 		}
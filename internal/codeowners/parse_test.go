@@ -374,5 +374,57 @@ func TestParse(t *testing.T) {
 			{LineNo: 2, Reason: "Unknown line type", Raw: "*"},
 		}, anomalies)
 	})
+}
+
+func TestParseExclusions(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("exclusions don't count as owners of a section", func(t *testing.T) {
+		codeOwnersLines, anomalies := Parse("[section] -@karl\n*")
+
+		assert.Equal("unknown", codeOwnersLines[1].Type)
+		assert.Equal(Anomalies{{LineNo: 2, Reason: "Unknown line type", Raw: "*"}}, anomalies)
+	})
+}
+
+func TestParseOwner(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("user-or-group", func(t *testing.T) {
+		assert.Equal(Owner{Type: "user-or-group", Name: "@ch/sales"}, ParseOwner("@ch/sales"))
+	})
+
+	t.Run("user-or-group with a role", func(t *testing.T) {
+		owner := ParseOwner("@ch/sales:maintainers")
 
+		assert.Equal(Owner{Type: "user-or-group", Name: "@ch/sales:maintainers", Role: "maintainers"}, owner)
+		assert.Equal("@ch/sales", owner.NameWithoutRole())
+	})
+
+	t.Run("e-mail", func(t *testing.T) {
+		assert.Equal(Owner{Type: "e-mail", Name: "jane@example.com"}, ParseOwner("jane@example.com"))
+	})
+
+	t.Run("exclusion", func(t *testing.T) {
+		owner := ParseOwner("-@karl-marx-ch")
+
+		assert.Equal(Owner{Type: "exclusion", Name: "-@karl-marx-ch"}, owner)
+		assert.Equal("@karl-marx-ch", owner.ExcludedName())
+		assert.Equal(Owner{Type: "exclusion", Name: "-karl@example.com"}, ParseOwner("-karl@example.com"))
+	})
+
+	t.Run("GitLab role", func(t *testing.T) {
+		assert.Equal(Owner{Type: "user-or-group", Name: "@@maintainer"}, ParseOwner("@@maintainer"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Equal(Owner{Type: "invalid", Name: "jane"}, ParseOwner("jane"))
+		assert.Equal(Owner{Type: "invalid", Name: "-karl"}, ParseOwner("-karl"))
+	})
+
+	t.Run("invalid on any platform", func(t *testing.T) {
+		for _, owner := range []string{"@", "@john_doe.", "@-john", "@john doe", "@org//team", "@@someone", "john@localhost", "john@@example.com"} {
+			assert.Equal("invalid", ParseOwner(owner).Type, owner)
+		}
+	})
 }
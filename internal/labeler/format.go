@@ -53,7 +53,8 @@ func getPatternsForTeam(team string, lines codeowners.CST) []string {
 	for _, line := range lines {
 		if line.Type == "rule" {
 			for _, owner := range line.Owners {
-				if owner.Name == "@"+team {
+				// teams and roles within the team all get the team's label
+				if owner.NameWithoutRole() == "@"+team {
 					returnValue = append(returnValue, transform(line.RulePattern))
 				}
 			}
@@ -54,4 +54,15 @@ func TestFormatLabeler(t *testing.T) {
 
 		assert.Contains(found, expected)
 	})
+	t.Run("roles within a team get the team's label", func(t *testing.T) {
+		parsed, _ := codeowners.Parse("libs/sales/ @ch/sales:maintainers\nlibs/refund/ @ch/sales")
+		teamMap := teams.Map{"ch/sales": {"jane", "karl"}}
+		expected := "ch/sales:\n" +
+			"  - changed-files:\n" +
+			"    - any-glob-to-any-file: libs/sales/**\n" +
+			"    - any-glob-to-any-file: libs/refund/**\n\n"
+		found, _ := FormatCST(parsed, teamMap, "")
+
+		assert.Equal(expected, found)
+	})
 }
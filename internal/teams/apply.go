@@ -0,0 +1,346 @@
+package teams
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func cookOwner(ownerString string) codeowners.Owner {
+	owner := codeowners.ParseOwner(ownerString)
+
+	// if an owner in a team map doesn't start with an @, ParseOwner will
+	// classify it as 'invalid'. However, that's how owners should appear in
+	// team maps => chuck an '@' in front of it and call it a regular 'user-or-group'
+	if owner.Type == "invalid" {
+		owner.Type = "user-or-group"
+		owner.Name = "@" + ownerString
+	}
+
+	return owner
+}
+
+func uniqOwners(owners []codeowners.Owner, normalizer caseNormalizer) []codeowners.Owner {
+	var visited = map[string]bool{}
+	var returnValue []codeowners.Owner
+
+	for _, owner := range owners {
+		if !visited[normalizer.key(owner.Name)] {
+			returnValue = append(returnValue, owner)
+			visited[normalizer.key(owner.Name)] = true
+		}
+	}
+	return returnValue
+}
+
+// Options influence how Apply expands teams
+type Options struct {
+	// How to order the owners on each line:
+	//   - "alphabetical" (default) sorts them by name
+	//   - "source" keeps them in the order they appear in, with team members
+	//     inserted in place of the team, in the order of the team map
+	//   - "team" groups them by team: first the owners that aren't a team in
+	//     the order they appear in, then the members of each team in the
+	//     order the teams appear in, sorted by name within the team
+	Ordering string
+	// Settings of the virtual teams, e.g. the real team handle to use instead
+	// of their members
+	Settings Settings
+	// When a virtual team has more members than this, Apply emits the
+	// fallback of the team instead (and warns about it). 0 means no limit.
+	LargeTeamThreshold int
+	// Seed for selecting members of teams that only want some of their
+	// members listed per rule (see Team.ReviewersPerRule)
+	Seed string
+	// When set, e-mail addresses are replaced by the handles of their owners
+	// on the Platform (e.g. "github" or "gitlab")
+	Identities codeowners.Identities
+	Platform   string
+	// How to treat the case of owner names: "none" (default), "lowercase"
+	// or "canonical" (see caseNormalizer)
+	Normalization string
+}
+
+type applyState struct {
+	warnedTeams map[string]bool
+	warnings    codeowners.Anomalies
+	selections  []codeowners.Selection
+	normalizer  caseNormalizer
+	// the (normalized) exclusions on the current line
+	exclusions []codeowners.Owner
+}
+
+func (state *applyState) warn(team string, line codeowners.Line, reason string) {
+	if state.warnedTeams[team] {
+		return
+	}
+	state.warnedTeams[team] = true
+	state.warnings = append(state.warnings, codeowners.Anomaly{
+		File:   line.File,
+		LineNo: line.LineNo,
+		Reason: reason,
+		Raw:    line.Raw,
+	})
+}
+
+// isExcluded tells whether the team member is excluded on the current line
+func (state *applyState) isExcluded(member string, options Options) bool {
+	owner := cookOwner(member)
+	if options.Identities != nil {
+		owner, _ = options.Identities.Normalize(owner, options.Platform)
+	}
+	return slices.ContainsFunc(state.exclusions, func(exclusion codeowners.Owner) bool {
+		return state.normalizer.key(owner.Name) == state.normalizer.key(exclusion.ExcludedName())
+	})
+}
+
+// getTeamOwners returns what to emit for a team: its members, its real
+// team handle and extra members, a selection of its members or - when the
+// team is too large - its fallback.
+func getTeamOwners(teamName string, members []string, line codeowners.Line, options Options, state *applyState) []codeowners.Owner {
+	var returnValue []codeowners.Owner
+	settings := options.Settings[teamName]
+
+	if settings.Handle != "" {
+		returnValue = append(returnValue, codeowners.ParseOwner(settings.Handle))
+		members = settings.ExtraMembers
+	} else if len(members) == 0 {
+		state.warn(teamName, line, fmt.Sprintf("Team '%s' has no active members, so it adds no owners", teamName))
+	} else if settings.ReviewersPerRule > 0 && settings.ReviewersPerRule < len(members) {
+		// select among the members that aren't excluded, so the line still
+		// gets as many reviewers as the team wants
+		candidates := slices.DeleteFunc(slices.Clone(members), func(member string) bool {
+			return state.isExcluded(member, options)
+		})
+		selected := candidates
+		if settings.ReviewersPerRule < len(candidates) {
+			selected = selectMembers(candidates, settings.ReviewersPerRule, options.Seed, getSelectionPattern(line))
+		}
+		state.selections = append(state.selections, codeowners.Selection{
+			Team:     teamName,
+			Members:  selected,
+			TeamSize: len(members),
+		})
+		members = selected
+	} else if options.LargeTeamThreshold > 0 && len(members) > options.LargeTeamThreshold {
+		reason := fmt.Sprintf(
+			"Team '%s' has %d members, which is more than %d",
+			teamName, len(members), options.LargeTeamThreshold,
+		)
+		if settings.Fallback != "" {
+			state.warn(teamName, line, reason+fmt.Sprintf("; using its fallback '%s' instead", settings.Fallback))
+			return []codeowners.Owner{codeowners.ParseOwner(settings.Fallback)}
+		}
+		if len(settings.Maintainers) > 0 {
+			state.warn(teamName, line, reason+"; using its maintainers instead")
+			members = settings.Maintainers
+		} else {
+			state.warn(teamName, line, reason+"; it has no fallback or maintainers, so using all members")
+		}
+	}
+
+	return append(returnValue, cookMembers(members, options)...)
+}
+
+// getRoleMembers returns the members of the team that have the role
+func getRoleMembers(role string, settings Team) ([]string, bool) {
+	if roleMembers, found := settings.Roles[role]; found {
+		return roleMembers, true
+	}
+	if role == "maintainers" && len(settings.Maintainers) > 0 {
+		return settings.Maintainers, true
+	}
+	return nil, false
+}
+
+func cookMembers(members []string, options Options) []codeowners.Owner {
+	var cookedMembers []codeowners.Owner
+	for _, member := range members {
+		cookedMembers = append(cookedMembers, cookOwner(member))
+	}
+	if options.Ordering == "team" {
+		sortOwners(cookedMembers, newCaseNormalizer(nil, options.Normalization))
+	}
+	return cookedMembers
+}
+
+func expandOwner(owner codeowners.Owner, line codeowners.Line, teamMap Map, options Options, state *applyState) []codeowners.Owner {
+	teamName := state.normalizer.lookupTeam(strings.TrimPrefix(owner.NameWithoutRole(), "@"))
+
+	if members := teamMap[teamName]; members != nil && owner.Type == "user-or-group" {
+		if owner.Role == "" {
+			return getTeamOwners(teamName, members, line, options, state)
+		}
+		if roleMembers, found := getRoleMembers(owner.Role, options.Settings[teamName]); found {
+			return cookMembers(roleMembers, options)
+		}
+		// unless the team declares a 'members' role itself, that's the
+		// whole team
+		if owner.Role == "members" {
+			return getTeamOwners(teamName, members, line, options, state)
+		}
+		state.warn(owner.Name, line, fmt.Sprintf("Team '%s' has no role '%s'", teamName, owner.Role))
+	} else {
+		warnAboutTeamCase(owner, line, teamMap, state)
+	}
+	return []codeowners.Owner{owner}
+}
+
+// isTeam tells whether the owner is one of the virtual teams
+func isTeam(owner codeowners.Owner, teamMap Map, normalizer caseNormalizer) bool {
+	return owner.Type == "user-or-group" && teamMap[normalizer.lookupTeam(strings.TrimPrefix(owner.NameWithoutRole(), "@"))] != nil
+}
+
+func sortOwners(owners []codeowners.Owner, normalizer caseNormalizer) {
+	slices.SortFunc(owners, normalizer.compare)
+}
+
+// Apply replaces the virtual teams in the CST with their members, sorted
+// alphabetically. It ignores exclusions (e.g. -@jane), so use
+// ApplyWithOptions when the lines can have them.
+func Apply(lines codeowners.CST, teamMap Map) codeowners.CST {
+	var linesWithoutExclusions codeowners.CST
+	for _, line := range lines {
+		line.Owners = slices.DeleteFunc(slices.Clone(line.Owners), func(owner codeowners.Owner) bool {
+			return owner.Type == "exclusion"
+		})
+		linesWithoutExclusions = append(linesWithoutExclusions, line)
+	}
+	// without exclusions there's nothing to fail on
+	transformedLines, _, _ := ApplyWithOptions(linesWithoutExclusions, teamMap, Options{})
+	return transformedLines
+}
+
+// ApplyWithOptions replaces the virtual teams in the CST with their members
+// as specified in the options and removes excluded owners. It also returns
+// warnings about things that might need attention, like teams that were too
+// large to expand.
+func ApplyWithOptions(lines codeowners.CST, teamMap Map, options Options) (codeowners.CST, codeowners.Anomalies, error) {
+	transformedLines := codeowners.CST{}
+	state := applyState{
+		warnedTeams: map[string]bool{},
+		normalizer:  newCaseNormalizer(teamMap, options.Normalization),
+	}
+
+	for _, line := range lines {
+		if line.Type == "rule" || line.Type == "section-heading" {
+			var newOwners []codeowners.Owner = []codeowners.Owner{}
+			var teamOwners []codeowners.Owner
+			state.selections = nil
+			state.exclusions = nil
+			for _, owner := range line.Owners {
+				if owner.Type == "exclusion" {
+					state.exclusions = append(state.exclusions, state.normalizer.normalize(resolveExclusion(owner, options)))
+				}
+			}
+			for _, owner := range line.Owners {
+				if owner.Type == "exclusion" {
+					continue
+				}
+				expandedOwners := expandOwner(owner, line, teamMap, options, &state)
+				if options.Ordering == "team" && isTeam(owner, teamMap, state.normalizer) {
+					teamOwners = append(teamOwners, expandedOwners...)
+					continue
+				}
+				newOwners = append(newOwners, expandedOwners...)
+			}
+			newOwners = append(newOwners, teamOwners...)
+			if options.Identities != nil {
+				newOwners = resolveIdentities(newOwners, line, options, &state)
+			}
+			warnAboutMixedCase(newOwners, line, &state)
+			for i := range newOwners {
+				newOwners[i] = state.normalizer.normalize(newOwners[i])
+			}
+			if options.Ordering != "source" && options.Ordering != "team" {
+				sortOwners(newOwners, state.normalizer)
+			}
+			potentialOwners := getPotentialOwners(line, teamMap, options, state.normalizer)
+			newOwners, exclusionError := excludeOwners(uniqOwners(newOwners, state.normalizer), state.exclusions, potentialOwners, line, state.normalizer)
+			if exclusionError != nil {
+				return nil, state.warnings, exclusionError
+			}
+			line.Owners = newOwners
+			line.Selections = state.selections
+		}
+		transformedLines = append(transformedLines, line)
+	}
+
+	return transformedLines, state.warnings, nil
+}
+
+// resolveIdentities replaces e-mail addresses with the handles of their
+// owners on the platform, and warns about those it has no handle for
+func resolveIdentities(owners []codeowners.Owner, line codeowners.Line, options Options, state *applyState) []codeowners.Owner {
+	var returnValue []codeowners.Owner
+
+	for _, owner := range owners {
+		normalizedOwner, found := options.Identities.Normalize(owner, options.Platform)
+		if !found {
+			state.warn(owner.Name, line, fmt.Sprintf("No %s handle for e-mail address '%s'", options.Platform, owner.Name))
+		}
+		returnValue = append(returnValue, normalizedOwner)
+	}
+	return returnValue
+}
+
+// resolveExclusion makes exclusions of e-mail addresses exclude the
+// handle the e-mail address is replaced with
+func resolveExclusion(exclusion codeowners.Owner, options Options) codeowners.Owner {
+	if options.Identities == nil {
+		return exclusion
+	}
+	normalizedOwner, _ := options.Identities.Normalize(codeowners.ParseOwner(exclusion.ExcludedName()), options.Platform)
+	return codeowners.Owner{Type: "exclusion", Name: "-" + normalizedOwner.Name}
+}
+
+// getPotentialOwners returns everyone who could be an owner of the line:
+// the owners on it and all members of the teams on it - also the ones that
+// weren't selected (see Team.ReviewersPerRule) or whose membership isn't
+// active (see Team.Windows).
+func getPotentialOwners(line codeowners.Line, teamMap Map, options Options, normalizer caseNormalizer) []codeowners.Owner {
+	var potentialOwners []codeowners.Owner
+	for _, owner := range line.Owners {
+		potentialOwners = append(potentialOwners, owner)
+		teamName := normalizer.lookupTeam(strings.TrimPrefix(owner.NameWithoutRole(), "@"))
+		settings := options.Settings[teamName]
+		members := slices.Concat(teamMap[teamName], settings.ExtraMembers, settings.Maintainers)
+		for member := range settings.Windows {
+			members = append(members, member)
+		}
+		for _, member := range members {
+			potentialOwners = append(potentialOwners, cookOwner(member))
+		}
+	}
+	for i, owner := range potentialOwners {
+		if options.Identities != nil {
+			owner, _ = options.Identities.Normalize(owner, options.Platform)
+		}
+		potentialOwners[i] = normalizer.normalize(owner)
+	}
+	return potentialOwners
+}
+
+// excludeOwners removes the excluded owners from the owners. Excluding a
+// potential owner that isn't among them (e.g. a team member that wasn't
+// selected for the line) changes nothing. It returns an error when an
+// excluded owner isn't a potential owner either, as that's probably a typo
+// or an outdated exclusion.
+func excludeOwners(owners []codeowners.Owner, exclusions []codeowners.Owner, potentialOwners []codeowners.Owner, line codeowners.Line, normalizer caseNormalizer) ([]codeowners.Owner, error) {
+	for _, exclusion := range exclusions {
+		excludedName := exclusion.ExcludedName()
+		isExcluded := func(owner codeowners.Owner) bool {
+			return normalizer.key(owner.Name) == normalizer.key(excludedName)
+		}
+		if !slices.ContainsFunc(potentialOwners, isExcluded) {
+			return nil, fmt.Errorf(
+				"%s: can't exclude '%s' as it isn't among the owners of the line: \"%s\"",
+				line.Location(), excludedName, line.Raw,
+			)
+		}
+		owners = slices.DeleteFunc(owners, isExcluded)
+	}
+	return owners, nil
+}
@@ -0,0 +1,679 @@
+package teams
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func TestApplyTeamMap(t *testing.T) {
+	assert := assert.New(t)
+	t.Run("replaces teams in CodeOwners CSTs - rule", func(t *testing.T) {
+		codeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@team2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team1",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		teamMap := Map{
+			"team1": {"user1", "user2"},
+			"team2": {"user3", "user4", "user5@somewhere.else.com"},
+		}
+		expectedCodeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@user1",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user3",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user4",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+					{
+						Type: "e-mail",
+						Name: "user5@somewhere.else.com",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		transformedCodeOwners := Apply(codeOwners, teamMap)
+
+		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	})
+
+	t.Run("replaces teams in CodeOwners CSTs & uniqs them - rule", func(t *testing.T) {
+		codeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@team2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team1",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		teamMap := Map{
+			"team1": {"user1", "user2", "not@replaced.nl"},
+			"team2": {"user3", "user4", "user2", "user1", "user5@somewhere.else.com"},
+		}
+		expectedCodeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@user1",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user3",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user4",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+					{
+						Type: "e-mail",
+						Name: "user5@somewhere.else.com",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		transformedCodeOwners := Apply(codeOwners, teamMap)
+
+		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	})
+
+	t.Run("replaces teams in CodeOwners CSTs - section-heading", func(t *testing.T) {
+		codeOwners := codeowners.CST{
+			{
+				Type:   "section-heading",
+				LineNo: 1,
+				Raw:    "[some_section] @team2 @team1 not@replaced.nl # comment with @team1",
+
+				SectionOptional:     false,
+				SectionName:         "some_section",
+				SectionMinApprovers: 0,
+				Spaces:              " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@team2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team1",
+					},
+					{
+						Type: "user-or-group",
+						// same as before - not a typo
+						Name: "@team1",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		teamMap := Map{
+			"team1": {"user1", "user2"},
+			"team2": {"user3", "user4", "user5@somewhere.else.com"},
+		}
+		expectedCodeOwners := codeowners.CST{
+			{
+				Type:   "section-heading",
+				LineNo: 1,
+				Raw:    "[some_section] @team2 @team1 not@replaced.nl # comment with @team1",
+
+				SectionOptional:     false,
+				SectionName:         "some_section",
+				SectionMinApprovers: 0,
+				Spaces:              " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@user1",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user3",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@user4",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+					{
+						Type: "e-mail",
+						Name: "user5@somewhere.else.com",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		transformedCodeOwners := Apply(codeOwners, teamMap)
+
+		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	})
+
+	t.Run("only sorts owners when the team map is empty", func(t *testing.T) {
+		codeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* not@replaced.nl @team2 @team3 @team1 # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team3",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team1",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		teamMap := Map{}
+		expectedCodeOwners := codeowners.CST{
+			{
+				Type:   "rule",
+				LineNo: 1,
+				Raw:    "* not@replaced.nl @team2 @team3 @team1 # comment with @team1",
+
+				RulePattern: "*",
+				Spaces:      " ",
+				Owners: []codeowners.Owner{
+					{
+						Type: "user-or-group",
+						Name: "@team1",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team2",
+					},
+					{
+						Type: "user-or-group",
+						Name: "@team3",
+					},
+					{
+						Type: "e-mail",
+						Name: "not@replaced.nl",
+					},
+				},
+				InlineComment: " comment with @team1",
+			},
+		}
+		transformedCodeOwners := Apply(codeOwners, teamMap)
+
+		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	})
+}
+
+func TestApplyTeamMapWithOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	codeOwners, _ := codeowners.Parse("* @zed @team2 @alice @team1 @bob")
+	teamMap := Map{
+		"team1": {"user4", "user3", "bob"},
+		"team2": {"user2", "user1"},
+	}
+	ownerNames := func(cst codeowners.CST) []string {
+		var names []string
+		for _, owner := range cst[0].Owners {
+			names = append(names, owner.Name)
+		}
+		return names
+	}
+
+	t.Run("alphabetical (the default) sorts all owners", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{})
+
+		assert.Equal(
+			[]string{"@alice", "@bob", "@user1", "@user2", "@user3", "@user4", "@zed"},
+			ownerNames(transformed),
+		)
+		transformedAlphabetically, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Ordering: "alphabetical"})
+		assert.Equal(transformed, transformedAlphabetically)
+	})
+
+	t.Run("source keeps the source order and inserts team members in place", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Ordering: "source"})
+
+		assert.Equal(
+			[]string{"@zed", "@user2", "@user1", "@alice", "@user4", "@user3", "@bob"},
+			ownerNames(transformed),
+		)
+	})
+
+	t.Run("team puts the other owners first, then groups team members by team and sorts them within their team", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Ordering: "team"})
+
+		assert.Equal(
+			[]string{"@zed", "@alice", "@bob", "@user1", "@user2", "@user3", "@user4"},
+			ownerNames(transformed),
+		)
+	})
+
+	t.Run("team groups the members of a team with a role with that team", func(t *testing.T) {
+		codeOwnersWithRole, _ := codeowners.Parse("* @team1:maintainers @zed @team2")
+		transformed, _, _ := ApplyWithOptions(codeOwnersWithRole, teamMap, Options{
+			Ordering: "team",
+			Settings: Settings{"team1": {Maintainers: []string{"user4", "user3"}}},
+		})
+
+		assert.Equal(
+			[]string{"@zed", "@user3", "@user4", "@user1", "@user2"},
+			ownerNames(transformed),
+		)
+	})
+}
+
+func TestApplyTeamMapWithHandles(t *testing.T) {
+	assert := assert.New(t)
+
+	codeOwners, _ := codeowners.Parse("* @team1 @team2")
+	teamMap := Map{
+		"team1": {"user1", "user2"},
+		"team2": {"user3", "user4"},
+	}
+
+	t.Run("emits the handle instead of the members", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{
+			Settings: Settings{"team2": {Handle: "@org/team2"}},
+		})
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@org/team2"},
+			{Type: "user-or-group", Name: "@user1"},
+			{Type: "user-or-group", Name: "@user2"},
+		}, transformed[0].Owners)
+	})
+
+	t.Run("emits the extra members along with the handle", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{
+			Ordering: "source",
+			Settings: Settings{"team1": {Handle: "@org/team1", ExtraMembers: []string{"user5"}}},
+		})
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@org/team1"},
+			{Type: "user-or-group", Name: "@user5"},
+			{Type: "user-or-group", Name: "@user3"},
+			{Type: "user-or-group", Name: "@user4"},
+		}, transformed[0].Owners)
+	})
+}
+
+func TestApplyTeamMapWithLargeTeamThreshold(t *testing.T) {
+	assert := assert.New(t)
+
+	codeOwners, _ := codeowners.Parse("* @team1 @user9\nsrc/ @team1\ndocs/ @team2")
+	teamMap := Map{
+		"team1": {"user1", "user2", "user3"},
+		"team2": {"user4", "user5"},
+	}
+
+	t.Run("below the threshold expands as usual", func(t *testing.T) {
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{LargeTeamThreshold: 3})
+
+		assert.Equal(Apply(codeOwners, teamMap), transformed)
+		assert.Equal(0, len(warnings))
+	})
+
+	t.Run("above the threshold emits the fallback handle & warns once per team", func(t *testing.T) {
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{
+			LargeTeamThreshold: 2,
+			Settings:           Settings{"team1": {Fallback: "@org/team1"}},
+		})
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@org/team1"},
+			{Type: "user-or-group", Name: "@user9"},
+		}, transformed[0].Owners)
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@org/team1"},
+		}, transformed[1].Owners)
+		assert.Equal(codeowners.Anomalies{
+			{LineNo: 1, Reason: "Team 'team1' has 3 members, which is more than 2; using its fallback '@org/team1' instead", Raw: "* @team1 @user9"},
+		}, warnings)
+	})
+
+	t.Run("above the threshold emits the maintainers when there's no fallback handle", func(t *testing.T) {
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{
+			LargeTeamThreshold: 2,
+			Settings:           Settings{"team1": {Maintainers: []string{"user3", "user1"}}},
+		})
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@user1"},
+			{Type: "user-or-group", Name: "@user3"},
+		}, transformed[1].Owners)
+		assert.Equal(codeowners.Anomalies{
+			{LineNo: 1, Reason: "Team 'team1' has 3 members, which is more than 2; using its maintainers instead", Raw: "* @team1 @user9"},
+		}, warnings)
+	})
+
+	t.Run("above the threshold without a fallback emits all members", func(t *testing.T) {
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{LargeTeamThreshold: 1})
+
+		assert.Equal(Apply(codeOwners, teamMap), transformed)
+		assert.Equal(codeowners.Anomalies{
+			{LineNo: 1, Reason: "Team 'team1' has 3 members, which is more than 1; it has no fallback or maintainers, so using all members", Raw: "* @team1 @user9"},
+			{LineNo: 3, Reason: "Team 'team2' has 2 members, which is more than 1; it has no fallback or maintainers, so using all members", Raw: "docs/ @team2"},
+		}, warnings)
+	})
+}
+
+func TestApplyTeamMapWithReviewersPerRule(t *testing.T) {
+	assert := assert.New(t)
+
+	codeOwners, _ := codeowners.Parse("src/ @team1 @user9\ndocs/ @team2")
+	teamMap := Map{
+		"team1": {"user1", "user2", "user3", "user4"},
+		"team2": {"user5", "user6"},
+	}
+	settings := Settings{
+		"team1": {ReviewersPerRule: 2},
+		"team2": {ReviewersPerRule: 2},
+	}
+
+	t.Run("lists the selected members & records the selection", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings, Seed: "seed"})
+		selected := selectMembers(teamMap["team1"], 2, "seed", "src/")
+
+		assert.Equal([]codeowners.Selection{
+			{Team: "team1", Members: selected, TeamSize: 4},
+		}, transformed[0].Selections)
+		assert.Equal(3, len(transformed[0].Owners))
+		assert.Contains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@" + selected[0]})
+		assert.Contains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@" + selected[1]})
+		assert.Contains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@user9"})
+	})
+
+	t.Run("teams that aren't larger than the number of reviewers are listed in full", func(t *testing.T) {
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings, Seed: "seed"})
+
+		assert.Nil(transformed[1].Selections)
+		assert.Equal(Apply(codeOwners, teamMap)[1], transformed[1])
+	})
+
+	t.Run("selects among the members that aren't excluded, so the line still gets as many reviewers", func(t *testing.T) {
+		excluded := selectMembers(teamMap["team1"], 2, "seed", "src/")[0]
+		codeOwnersWithExclusion, _ := codeowners.Parse("src/ @team1 @user9 -@" + excluded)
+		transformed, _, error := ApplyWithOptions(codeOwnersWithExclusion, teamMap, Options{Settings: settings, Seed: "seed"})
+		selected := selectMembers(slices.DeleteFunc(slices.Clone(teamMap["team1"]), func(member string) bool {
+			return member == excluded
+		}), 2, "seed", "src/")
+
+		assert.Nil(error)
+		assert.NotContains(selected, excluded)
+		assert.Equal([]codeowners.Selection{
+			{Team: "team1", Members: selected, TeamSize: 4},
+		}, transformed[0].Selections)
+		assert.Equal(3, len(transformed[0].Owners))
+		assert.Contains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@" + selected[0]})
+		assert.Contains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@" + selected[1]})
+		assert.NotContains(transformed[0].Owners, codeowners.Owner{Type: "user-or-group", Name: "@" + excluded})
+	})
+}
+
+func TestApplyTeamMapWithRoles(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap := Map{
+		"team1": {"user1", "user2", "user3", "user4"},
+		"team2": {"user5", "user6"},
+	}
+	settings := Settings{
+		"team1": {
+			Maintainers: []string{"user1"},
+			Roles:       map[string][]string{"reviewers": {"user2", "user3"}},
+		},
+		"team2": {
+			Roles: map[string][]string{"members": {"user6"}},
+		},
+	}
+	ownerNames := func(owners []codeowners.Owner) []string {
+		var names []string
+		for _, owner := range owners {
+			names = append(names, owner.Name)
+		}
+		return names
+	}
+
+	t.Run("expands a declared role", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team1:reviewers @user9")
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings})
+
+		assert.Equal([]string{"@user2", "@user3", "@user9"}, ownerNames(transformed[0].Owners))
+		assert.Equal(0, len(warnings))
+	})
+
+	t.Run("expands the maintainers role to the maintainers", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team1:maintainers")
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings})
+
+		assert.Equal([]string{"@user1"}, ownerNames(transformed[0].Owners))
+	})
+
+	t.Run("expands the members role to the whole team, unless declared", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team1:members\nsrc/ @team2:members")
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings})
+
+		assert.Equal([]string{"@user1", "@user2", "@user3", "@user4"}, ownerNames(transformed[0].Owners))
+		assert.Equal([]string{"@user6"}, ownerNames(transformed[1].Owners))
+	})
+
+	t.Run("leaves unknown roles alone and warns about them", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team2:maintainers")
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{Settings: settings})
+
+		assert.Equal([]string{"@team2:maintainers"}, ownerNames(transformed[0].Owners))
+		assert.Equal(codeowners.Anomalies{
+			{LineNo: 1, Reason: "Team 'team2' has no role 'maintainers'", Raw: "* @team2:maintainers"},
+		}, warnings)
+	})
+}
+
+func TestApplyTeamMapWithExclusions(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap := Map{
+		"ch/sales": {"jane-doe-ch", "karl-marx-ch", "jan@example.com"},
+	}
+
+	t.Run("removes excluded owners after expanding the teams", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("libs/refund/ @ch/sales -@karl-marx-ch -jan@example.com @john")
+		transformed, _, error := ApplyWithOptions(codeOwners, teamMap, Options{})
+
+		assert.Nil(error)
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@jane-doe-ch"},
+			{Type: "user-or-group", Name: "@john"},
+		}, transformed[0].Owners)
+	})
+
+	t.Run("excluding members that weren't selected or aren't active changes nothing", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("libs/refund/ @ch/sales -@karl-marx-ch -@jan-ch")
+		transformed, _, error := ApplyWithOptions(codeOwners, Map{"ch/sales": {"jane-doe-ch", "karl-marx-ch"}}, Options{
+			Settings: Settings{"ch/sales": {
+				ReviewersPerRule: 1,
+				Windows:          map[string]Window{"jan-ch": {}},
+			}},
+			Seed: "2026",
+		})
+
+		assert.Nil(error)
+		assert.Equal([]codeowners.Owner{{Type: "user-or-group", Name: "@jane-doe-ch"}}, transformed[0].Owners)
+	})
+
+	t.Run("Apply ignores exclusions", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("libs/refund/ @ch/sales -@karl-marx-ch -@karl-marx")
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@jane-doe-ch"},
+			{Type: "user-or-group", Name: "@karl-marx-ch"},
+			{Type: "e-mail", Name: "jan@example.com"},
+		}, Apply(codeOwners, teamMap)[0].Owners)
+	})
+
+	t.Run("error: excluded owner isn't among the owners", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("# comment\nlibs/refund/ @ch/sales -@karl-marx")
+		_, _, error := ApplyWithOptions(codeOwners, teamMap, Options{})
+
+		assert.NotNil(error)
+		assert.Equal(
+			"line 2: can't exclude '@karl-marx' as it isn't among the owners of the line: \"libs/refund/ @ch/sales -@karl-marx\"",
+			error.Error(),
+		)
+	})
+}
+
+func TestApplyTeamMapWithoutActiveMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap, settings, _ := ParseWithSettings(`{"ch/sales": [{"name": "karl-marx-ch", "until": "2026-06-30"}]}`)
+	activeTeamMap, activeSettings, _ := ActiveOn(teamMap, settings, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+	codeOwners, _ := codeowners.Parse("libs/sales/ @ch/sales\nlibs/refunds/ @ch/sales @jane")
+
+	transformed, warnings, _ := ApplyWithOptions(codeOwners, activeTeamMap, Options{Settings: activeSettings})
+
+	assert.Empty(transformed[0].Owners)
+	assert.Equal(codeowners.Anomalies{
+		{LineNo: 1, Reason: "Team 'ch/sales' has no active members, so it adds no owners", Raw: "libs/sales/ @ch/sales"},
+	}, warnings)
+}
+
+func TestApplyTeamMapWithIdentities(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap := Map{
+		"team-beard": {"jan@example.com", "pier@example.com"},
+	}
+	identities := codeowners.Identities{
+		"jan@example.com":    {"github": "jan-gh", "gitlab": "jan-gl"},
+		"tjorus@example.com": {"github": "tjorus-gh"},
+	}
+
+	t.Run("replaces e-mail addresses of teams and lines with handles & warns about unknown ones", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team-beard tjorus@example.com\nsrc/ @team-beard")
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{Identities: identities, Platform: "github"})
+
+		assert.Equal([]codeowners.Owner{
+			{Type: "user-or-group", Name: "@jan-gh"},
+			{Type: "user-or-group", Name: "@tjorus-gh"},
+			{Type: "e-mail", Name: "pier@example.com"},
+		}, transformed[0].Owners)
+		assert.Equal(codeowners.Anomalies{
+			{LineNo: 1, Reason: "No github handle for e-mail address 'pier@example.com'", Raw: "* @team-beard tjorus@example.com"},
+		}, warnings)
+	})
+
+	t.Run("excluded e-mail addresses exclude their handle", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team-beard -jan@example.com")
+		transformed, _, error := ApplyWithOptions(codeOwners, teamMap, Options{Identities: identities, Platform: "github"})
+
+		assert.Nil(error)
+		assert.Equal([]codeowners.Owner{{Type: "e-mail", Name: "pier@example.com"}}, transformed[0].Owners)
+	})
+
+	t.Run("uses the handles of the platform", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @team-beard")
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Identities: identities, Platform: "gitlab"})
+
+		assert.Equal(codeowners.Owner{Type: "user-or-group", Name: "@jan-gl"}, transformed[0].Owners[0])
+	})
+}
@@ -0,0 +1,132 @@
+package teams
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// caseNormalizer normalizes the case of owner names. GitHub treats handles
+// case insensitively, so @Jane and @jane are the same person and @CH/Sales
+// is the same team as @ch/sales.
+//
+// Normalizations:
+//   - "none" (default): compare names case sensitively & leave them alone
+//   - "lowercase": compare names case insensitively & emit them in lower case
+//   - "canonical": compare names case insensitively & emit them as they're
+//     spelled in the team map (other names as their first occurrence)
+type caseNormalizer struct {
+	normalization  string
+	teamNames      map[string]string
+	canonicalNames map[string]string
+}
+
+func newCaseNormalizer(teamMap Map, normalization string) caseNormalizer {
+	normalizer := caseNormalizer{
+		normalization:  normalization,
+		teamNames:      map[string]string{},
+		canonicalNames: map[string]string{},
+	}
+	if !normalizer.isCaseInsensitive() {
+		return normalizer
+	}
+	for _, team := range slices.Sorted(maps.Keys(teamMap)) {
+		normalizer.teamNames[strings.ToLower(team)] = team
+		for _, member := range teamMap[team] {
+			memberName := cookOwner(member).Name
+			if _, found := normalizer.canonicalNames[strings.ToLower(memberName)]; !found {
+				normalizer.canonicalNames[strings.ToLower(memberName)] = memberName
+			}
+		}
+	}
+	return normalizer
+}
+
+func (normalizer caseNormalizer) isCaseInsensitive() bool {
+	return normalizer.normalization == "lowercase" || normalizer.normalization == "canonical"
+}
+
+// lookupTeam returns the name of the team as it is in the team map
+func (normalizer caseNormalizer) lookupTeam(teamName string) string {
+	if canonicalTeamName, found := normalizer.teamNames[strings.ToLower(teamName)]; found {
+		return canonicalTeamName
+	}
+	return teamName
+}
+
+func (normalizer caseNormalizer) normalizeName(name string) string {
+	switch normalizer.normalization {
+	case "lowercase":
+		return strings.ToLower(name)
+	case "canonical":
+		if canonicalName, found := normalizer.canonicalNames[strings.ToLower(name)]; found {
+			return canonicalName
+		}
+	}
+	return name
+}
+
+// normalize returns the owner with its name in the normalized case
+func (normalizer caseNormalizer) normalize(owner codeowners.Owner) codeowners.Owner {
+	if owner.Type == "exclusion" {
+		owner.Name = "-" + normalizer.normalizeName(owner.ExcludedName())
+		return owner
+	}
+	owner.Name = normalizer.normalizeName(owner.Name)
+	return owner
+}
+
+// key returns what to compare owner names on
+func (normalizer caseNormalizer) key(name string) string {
+	if normalizer.isCaseInsensitive() {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func (normalizer caseNormalizer) compare(a codeowners.Owner, b codeowners.Owner) int {
+	return cmp.Or(
+		cmp.Compare(normalizer.key(a.Name), normalizer.key(b.Name)),
+		cmp.Compare(a.Name, b.Name),
+	)
+}
+
+// warnAboutMixedCase warns about owners that only differ in case, as they're
+// probably the same user or team
+func warnAboutMixedCase(owners []codeowners.Owner, line codeowners.Line, state *applyState) {
+	var spellings = map[string]string{}
+
+	for _, owner := range owners {
+		lowerCaseName := strings.ToLower(owner.Name)
+		if spelling, found := spellings[lowerCaseName]; found && spelling != owner.Name {
+			state.warn(
+				"case:"+lowerCaseName, line,
+				fmt.Sprintf("Owners '%s' and '%s' only differ in case", spelling, owner.Name),
+			)
+		}
+		spellings[lowerCaseName] = owner.Name
+	}
+}
+
+// warnAboutTeamCase warns about an owner that isn't a team, but only differs
+// in case from one. Unless owner names are normalized that owner isn't
+// expanded, which is easy to miss.
+func warnAboutTeamCase(owner codeowners.Owner, line codeowners.Line, teamMap Map, state *applyState) {
+	if state.normalizer.isCaseInsensitive() || owner.Type != "user-or-group" {
+		return
+	}
+	name := strings.TrimPrefix(owner.NameWithoutRole(), "@")
+	for _, team := range slices.Sorted(maps.Keys(teamMap)) {
+		if team != name && strings.EqualFold(team, name) {
+			state.warn(
+				"team-case:"+owner.Name, line,
+				fmt.Sprintf("Owner '%s' only differs in case from team '%s', so it isn't expanded; normalize owners (lowercase or canonical) to expand it", owner.Name, team),
+			)
+			return
+		}
+	}
+}
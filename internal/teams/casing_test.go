@@ -0,0 +1,64 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func TestApplyTeamMapWithNormalization(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap := Map{
+		"ch/sales": {"Jane-Doe", "karl"},
+	}
+	ownerNames := func(owners []codeowners.Owner) []string {
+		var names []string
+		for _, owner := range owners {
+			names = append(names, owner.Name)
+		}
+		return names
+	}
+
+	t.Run("none (the default) compares case sensitively, but warns about mixed case duplicates and teams", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @CH/Sales @jane-doe @Bob\nsrc/ @ch/sales @jane-doe")
+		transformed, warnings, _ := ApplyWithOptions(codeOwners, teamMap, Options{})
+
+		assert.Equal([]string{"@Bob", "@CH/Sales", "@jane-doe"}, ownerNames(transformed[0].Owners))
+		assert.Equal([]string{"@Jane-Doe", "@jane-doe", "@karl"}, ownerNames(transformed[1].Owners))
+		assert.Equal(codeowners.Anomalies{
+			{
+				LineNo: 1,
+				Reason: "Owner '@CH/Sales' only differs in case from team 'ch/sales', so it isn't expanded; normalize owners (lowercase or canonical) to expand it",
+				Raw:    "* @CH/Sales @jane-doe @Bob",
+			},
+			{LineNo: 2, Reason: "Owners '@Jane-Doe' and '@jane-doe' only differ in case", Raw: "src/ @ch/sales @jane-doe"},
+		}, warnings)
+	})
+
+	t.Run("lowercase looks teams up, dedupes & sorts case insensitively and emits lower case", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @CH/Sales @jane-doe @Bob -@KARL")
+		transformed, _, error := ApplyWithOptions(codeOwners, teamMap, Options{Normalization: "lowercase"})
+
+		assert.Nil(error)
+		assert.Equal([]string{"@bob", "@jane-doe"}, ownerNames(transformed[0].Owners))
+	})
+
+	t.Run("canonical emits names as spelled in the team map", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @CH/Sales @jane-doe @Bob @KARL")
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{Normalization: "canonical"})
+
+		assert.Equal([]string{"@Bob", "@Jane-Doe", "@karl"}, ownerNames(transformed[0].Owners))
+	})
+
+	t.Run("settings are found for teams spelled differently", func(t *testing.T) {
+		codeOwners, _ := codeowners.Parse("* @CH/SALES")
+		transformed, _, _ := ApplyWithOptions(codeOwners, teamMap, Options{
+			Normalization: "canonical",
+			Settings:      Settings{"ch/sales": {Handle: "@org/sales"}},
+		})
+
+		assert.Equal([]string{"@org/sales"}, ownerNames(transformed[0].Owners))
+	})
+}
@@ -0,0 +1,59 @@
+package teams
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// MembershipChange lists who joined and who left a team
+type MembershipChange struct {
+	Team    string
+	Added   []string
+	Removed []string
+}
+
+// MembershipChanges are the membership changes between two team maps
+type MembershipChanges []MembershipChange
+
+// DiffMembers returns the members that were added to and removed from each
+// team, sorted by team name. Teams without changes aren't in there.
+func DiffMembers(before Map, after Map) MembershipChanges {
+	var changes MembershipChanges
+	teamNames := slices.Sorted(maps.Keys(before))
+	for team := range after {
+		if _, found := before[team]; !found {
+			teamNames = append(teamNames, team)
+		}
+	}
+	slices.Sort(teamNames)
+
+	for _, team := range teamNames {
+		added := codeowners.Difference(after[team], before[team])
+		removed := codeowners.Difference(before[team], after[team])
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, MembershipChange{Team: team, Added: added, Removed: removed})
+		}
+	}
+	return changes
+}
+
+func (changes MembershipChanges) String() string {
+	if len(changes) == 0 {
+		return "No membership changes\n"
+	}
+	var returnValue strings.Builder
+
+	for _, change := range changes {
+		returnValue.WriteString(change.Team + "\n")
+		for _, member := range change.Added {
+			returnValue.WriteString("  + " + member + "\n")
+		}
+		for _, member := range change.Removed {
+			returnValue.WriteString("  - " + member + "\n")
+		}
+	}
+	return returnValue.String()
+}
@@ -0,0 +1,32 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("added and removed members per team", func(t *testing.T) {
+		changes := DiffMembers(
+			Map{"ch/sales": {"jane", "karl"}, "ch/ux": {"davy"}, "ch/gone": {"john"}},
+			Map{"ch/sales": {"karl", "gregory"}, "ch/ux": {"davy"}, "ch/new": {"dagny"}},
+		)
+
+		assert.Equal(MembershipChanges{
+			{Team: "ch/gone", Removed: []string{"john"}},
+			{Team: "ch/new", Added: []string{"dagny"}},
+			{Team: "ch/sales", Added: []string{"gregory"}, Removed: []string{"jane"}},
+		}, changes)
+		assert.Equal("ch/gone\n  - john\nch/new\n  + dagny\nch/sales\n  + gregory\n  - jane\n", changes.String())
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		changes := DiffMembers(Map{"ch/ux": {"davy"}}, Map{"ch/ux": {"davy"}})
+
+		assert.Nil(changes)
+		assert.Equal("No membership changes\n", changes.String())
+	})
+}
@@ -0,0 +1,230 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// formattedTeam is the shape of a team with settings in a team map, with
+// the members first
+type formattedTeam struct {
+	Members []member `json:"members"`
+	Team
+}
+
+func hasSettings(team Team) bool {
+	return team.Handle != "" ||
+		team.ExtraMembers != nil ||
+		team.Fallback != "" ||
+		team.Maintainers != nil ||
+		team.Roles != nil ||
+		team.ReviewersPerRule != 0
+}
+
+func formatMembers(members []string, windows map[string]Window) []member {
+	returnValue := []member{}
+
+	for _, name := range members {
+		formattedMember := member{Name: name}
+		if window, found := windows[name]; found {
+			if !window.From.IsZero() {
+				formattedMember.From = window.From.Format(dateLayout)
+			}
+			if !window.Until.IsZero() {
+				formattedMember.Until = window.Until.Format(dateLayout)
+			}
+		}
+		returnValue = append(returnValue, formattedMember)
+	}
+	return returnValue
+}
+
+// formatTeam returns what Format emits for a team
+func formatTeam(members []string, teamSettings Team) any {
+	formattedMembers := formatMembers(members, teamSettings.Windows)
+	if hasSettings(teamSettings) {
+		return formattedTeam{Members: formattedMembers, Team: teamSettings}
+	}
+	return formattedMembers
+}
+
+// Format returns the team map and its settings in the JSON format Parse
+// and ParseWithSettings understand. Teams without settings are just a list
+// of members.
+func Format(teamMap Map, settings Settings) (string, error) {
+	formatted := map[string]any{}
+
+	for team, members := range teamMap {
+		formatted[team] = formatTeam(members, settings[team])
+	}
+
+	formattedBytes, error := json.MarshalIndent(formatted, "", "  ")
+	if error != nil {
+		return "", error
+	}
+	return string(formattedBytes) + "\n", nil
+}
+
+// teamSpan is where a team is defined in the text of a team map
+type teamSpan struct {
+	name       string
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// findTeamSpans returns where each team is defined in the text of a team map
+func findTeamSpans(teamMapString string) ([]teamSpan, error) {
+	decoder := json.NewDecoder(strings.NewReader(teamMapString))
+	if _, error := decoder.Token(); error != nil {
+		return nil, error
+	}
+	var spans []teamSpan
+	for decoder.More() {
+		keyStart := int(decoder.InputOffset())
+		token, error := decoder.Token()
+		if error != nil {
+			return nil, error
+		}
+		name, _ := token.(string)
+		keyStart = keyStart + strings.IndexByte(teamMapString[keyStart:], '"')
+		keyEnd := int(decoder.InputOffset())
+		var value json.RawMessage
+		if error := decoder.Decode(&value); error != nil {
+			return nil, error
+		}
+		valueEnd := int(decoder.InputOffset())
+		spans = append(spans, teamSpan{name, keyStart, keyEnd, valueEnd - len(value), valueEnd})
+	}
+	return spans, nil
+}
+
+// lineIndent returns the whitespace the line the position is on starts with
+func lineIndent(text string, position int) string {
+	line := text[strings.LastIndexByte(text[:position], '\n')+1 : position]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// spaceOut adds a space after the commas and colons in compact JSON, e.g.
+// ["jane", "karl"]
+func spaceOut(compact string) string {
+	var builder strings.Builder
+	inString := false
+	escaped := false
+	for _, character := range compact {
+		builder.WriteRune(character)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && character == '\\':
+			escaped = true
+		case character == '"':
+			inString = !inString
+		case !inString && (character == ',' || character == ':'):
+			builder.WriteRune(' ')
+		}
+	}
+	return builder.String()
+}
+
+// formatValueLike formats the value in the layout of the example: indented
+// from the indent on when the example spans lines, and on one line otherwise -
+// without spaces only when the example has none either
+func formatValueLike(value any, example string, indent string) (string, error) {
+	if strings.Contains(example, "\n") {
+		indentUnit := indent
+		if indentUnit == "" {
+			indentUnit = "  "
+		}
+		formattedBytes, error := json.MarshalIndent(value, indent, indentUnit)
+		return string(formattedBytes), error
+	}
+	formattedBytes, error := json.Marshal(value)
+	var compactExample bytes.Buffer
+	if json.Compact(&compactExample, []byte(example)) == nil &&
+		compactExample.String() == example && spaceOut(example) != example {
+		return string(formattedBytes), error
+	}
+	return spaceOut(string(formattedBytes)), error
+}
+
+// FormatLike returns the team map and its settings like Format does, but in
+// the layout of the original team map, so edits stay small: teams that
+// didn't change keep their text, teams that did are formatted in the layout
+// they had, and new teams go at the end in the layout of the first team.
+func FormatLike(original string, teamMap Map, settings Settings) (string, error) {
+	originalMap, originalSettings, parseError := ParseWithSettings(original)
+	spans, spansError := findTeamSpans(original)
+	if parseError != nil || spansError != nil || len(spans) == 0 {
+		return Format(teamMap, settings)
+	}
+
+	separatorAfter := func(i int) string {
+		if i+1 < len(spans) {
+			return original[spans[i].valueEnd:spans[i+1].keyStart]
+		}
+		if len(spans) > 1 {
+			return original[spans[0].valueEnd:spans[1].keyStart]
+		}
+		if strings.Contains(original[:spans[0].keyStart], "\n") {
+			return ",\n" + lineIndent(original, spans[0].keyStart)
+		}
+		return ", "
+	}
+	var builder strings.Builder
+	builder.WriteString(original[:spans[0].keyStart])
+	previous := -1
+	written := map[string]bool{}
+	for i, span := range spans {
+		members, found := teamMap[span.name]
+		if !found || written[span.name] {
+			continue
+		}
+		written[span.name] = true
+		if previous >= 0 {
+			builder.WriteString(separatorAfter(previous))
+		}
+		previous = i
+
+		value := original[span.valueStart:span.valueEnd]
+		unchangedBytes, _ := json.Marshal(formatTeam(originalMap[span.name], originalSettings[span.name]))
+		formattedBytes, _ := json.Marshal(formatTeam(members, settings[span.name]))
+		if string(unchangedBytes) != string(formattedBytes) {
+			formattedValue, formatError := formatValueLike(formatTeam(members, settings[span.name]), value, lineIndent(original, span.keyStart))
+			if formatError != nil {
+				return "", formatError
+			}
+			value = formattedValue
+		}
+		builder.WriteString(original[span.keyStart:span.valueStart] + value)
+	}
+
+	for _, team := range slices.Sorted(func(yield func(string) bool) {
+		for team := range teamMap {
+			if !written[team] && !yield(team) {
+				return
+			}
+		}
+	}) {
+		keyBytes, _ := json.Marshal(team)
+		value, formatError := formatValueLike(
+			formatTeam(teamMap[team], settings[team]),
+			original[spans[0].valueStart:spans[0].valueEnd],
+			lineIndent(original, spans[0].keyStart),
+		)
+		if formatError != nil {
+			return "", formatError
+		}
+		if previous >= 0 {
+			builder.WriteString(separatorAfter(previous))
+		}
+		previous = len(spans) - 1
+		builder.WriteString(string(keyBytes) + original[spans[0].keyEnd:spans[0].valueStart] + value)
+	}
+
+	builder.WriteString(original[spans[len(spans)-1].valueEnd:])
+	return builder.String(), nil
+}
@@ -0,0 +1,155 @@
+package teams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("teams without settings are a list of members", func(t *testing.T) {
+		formatted, error := Format(Map{"ch/ux": {"davy", "john"}, "ch/empty": {}}, nil)
+
+		assert.Nil(error)
+		assert.Equal(`{
+  "ch/empty": [],
+  "ch/ux": [
+    "davy",
+    "john"
+  ]
+}
+`, formatted)
+	})
+
+	t.Run("teams with settings are an object, members with a window an object too", func(t *testing.T) {
+		formatted, error := Format(
+			Map{"ch/sales": {"jane", "karl"}},
+			Settings{"ch/sales": {
+				Maintainers: []string{"jane"},
+				Windows:     map[string]Window{"karl": {Until: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)}},
+			}},
+		)
+
+		assert.Nil(error)
+		assert.Equal(`{
+  "ch/sales": {
+    "members": [
+      "jane",
+      {
+        "name": "karl",
+        "until": "2026-06-30"
+      }
+    ],
+    "maintainers": [
+      "jane"
+    ]
+  }
+}
+`, formatted)
+	})
+
+	t.Run("round trips through ParseWithSettings", func(t *testing.T) {
+		teamMap := Map{"ch/sales": {"jane", "karl"}, "ch/ux": {"davy"}}
+		settings := Settings{
+			"ch/sales": {
+				Handle:       "@org/sales",
+				ExtraMembers: []string{"karl"},
+				Roles:        map[string][]string{"reviewers": {"karl"}},
+				Windows:      map[string]Window{"jane": {From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			},
+		}
+		formatted, _ := Format(teamMap, settings)
+		parsedTeamMap, parsedSettings, error := ParseWithSettings(formatted)
+
+		assert.Nil(error)
+		assert.Equal(teamMap, parsedTeamMap)
+		assert.Equal(settings, parsedSettings)
+	})
+}
+
+func TestFormatLike(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("only changes the team that changed, in its own layout", func(t *testing.T) {
+		original := `{
+    "ch/ux": ["davy"],
+    "ch/sales": [
+        "jane"
+    ],
+    "ch/engineering": [ "karl" ]
+}
+`
+		formatted, error := FormatLike(
+			original,
+			Map{"ch/ux": {"davy", "ulrike"}, "ch/sales": {"jane", "john"}, "ch/engineering": {"karl"}},
+			nil,
+		)
+
+		assert.Nil(error)
+		assert.Equal(`{
+    "ch/ux": ["davy", "ulrike"],
+    "ch/sales": [
+        "jane",
+        "john"
+    ],
+    "ch/engineering": [ "karl" ]
+}
+`, formatted)
+	})
+
+	t.Run("keeps the original text when nothing changed", func(t *testing.T) {
+		original := `{"ch/sales":["karl","jane"],   "ch/ux" : ["davy"]}`
+		formatted, error := FormatLike(original, Map{"ch/sales": {"karl", "jane"}, "ch/ux": {"davy"}}, nil)
+
+		assert.Nil(error)
+		assert.Equal(original, formatted)
+	})
+
+	t.Run("adds new teams at the end, in the layout of the first", func(t *testing.T) {
+		formatted, error := FormatLike(
+			"{\n  \"ch/ux\": [\n    \"davy\"\n  ]\n}\n",
+			Map{"ch/ux": {"davy"}, "ch/sales": {"jane"}},
+			nil,
+		)
+
+		assert.Nil(error)
+		assert.Equal("{\n  \"ch/ux\": [\n    \"davy\"\n  ],\n  \"ch/sales\": [\n    \"jane\"\n  ]\n}\n", formatted)
+	})
+
+	t.Run("leaves out teams that are gone", func(t *testing.T) {
+		formatted, error := FormatLike(
+			`{"ch/ux": ["davy"], "ch/sales": ["jane"], "ch/engineering": ["karl"]}`,
+			Map{"ch/engineering": {"karl"}},
+			nil,
+		)
+
+		assert.Nil(error)
+		assert.Equal(`{"ch/engineering": ["karl"]}`, formatted)
+	})
+
+	t.Run("formats a team that gets settings like Format does", func(t *testing.T) {
+		formatted, error := FormatLike(
+			"{\n  \"ch/sales\": [\n    \"jane\",\n    \"karl\"\n  ]\n}\n",
+			Map{"ch/sales": {"jane", "karl"}},
+			Settings{"ch/sales": {Windows: map[string]Window{"karl": {Until: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)}}}},
+		)
+		expected, _ := Format(
+			Map{"ch/sales": {"jane", "karl"}},
+			Settings{"ch/sales": {Windows: map[string]Window{"karl": {Until: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)}}}},
+		)
+
+		assert.Nil(error)
+		assert.Equal(expected, formatted)
+	})
+
+	t.Run("falls back to Format when there's no team map to follow", func(t *testing.T) {
+		formatted, error := FormatLike("", Map{"ch/ux": {"davy"}}, nil)
+		expected, _ := Format(Map{"ch/ux": {"davy"}}, nil)
+
+		assert.Nil(error)
+		assert.Equal(expected, formatted)
+	})
+}
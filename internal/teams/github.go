@@ -0,0 +1,138 @@
+package teams
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// gitHubTeam holds the fields of a team the GitHub REST API returns
+// (GET /orgs/{org}/teams) that ParseGitHubTeams uses
+type gitHubTeam struct {
+	Slug    string `json:"slug"`
+	HTMLURL string `json:"html_url"`
+	Parent  *struct {
+		Slug string `json:"slug"`
+	} `json:"parent"`
+	Organization *struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+}
+
+// gitHubUser holds the field of a user the GitHub REST API returns (GET
+// /orgs/{org}/teams/{team_slug}/members) that ParseGitHubTeams uses
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+// gitHubTeamsExport is the shape of an export of the teams of a GitHub
+// organization and their members
+type gitHubTeamsExport struct {
+	Teams   []gitHubTeam            `json:"teams"`
+	Members map[string][]gitHubUser `json:"members"`
+}
+
+// name returns the name of the team as it's used in CODEOWNERS, i.e.
+// org/slug, or just the slug when the export doesn't tell the organization
+func (team gitHubTeam) name() string {
+	organizationLogin := ""
+	if team.Organization != nil {
+		organizationLogin = team.Organization.Login
+	}
+	if organization := codeowners.GitHubTeamOrganization(organizationLogin, team.HTMLURL); organization != "" {
+		return organization + "/" + team.Slug
+	}
+	return team.Slug
+}
+
+// parseGitHubTeamsExport parses an export of GitHub teams, and returns an
+// error when the string isn't one
+func parseGitHubTeamsExport(teamsString string) (gitHubTeamsExport, error) {
+	notAnExportError := fmt.Errorf("not a GitHub teams export; that's an object with 'teams' (each with a 'slug') and their 'members'")
+
+	var rawExport map[string]json.RawMessage
+	if error := json.Unmarshal([]byte(teamsString), &rawExport); error != nil {
+		return gitHubTeamsExport{}, error
+	}
+	if rawExport["teams"] == nil {
+		return gitHubTeamsExport{}, notAnExportError
+	}
+	for key := range rawExport {
+		if key != "teams" && key != "members" {
+			return gitHubTeamsExport{}, notAnExportError
+		}
+	}
+	var export gitHubTeamsExport
+	if json.Unmarshal([]byte(teamsString), &export) != nil {
+		return gitHubTeamsExport{}, notAnExportError
+	}
+	for _, team := range export.Teams {
+		if team.Slug == "" {
+			return gitHubTeamsExport{}, notAnExportError
+		}
+	}
+	return export, nil
+}
+
+// ParseGitHubTeams turns an export of the teams of a GitHub organization
+// and their members into a team map. The export combines what the GitHub
+// REST API returns for the teams (GET /orgs/{org}/teams) and for the
+// members of each team (GET /orgs/{org}/teams/{team_slug}/members), keyed
+// by the team's slug:
+//
+//	{
+//	  "teams": [
+//	    {"slug": "sales", "html_url": "https://github.com/orgs/cloud-heroes/teams/sales", "parent": null},
+//	    {"slug": "sales-emea", "html_url": "https://github.com/orgs/cloud-heroes/teams/sales-emea", "parent": {"slug": "sales"}}
+//	  ],
+//	  "members": {
+//	    "sales": [{"login": "jane-doe-ch"}],
+//	    "sales-emea": [{"login": "karl-marx-ch"}]
+//	  }
+//	}
+//
+// becomes {"cloud-heroes/sales": ["jane-doe-ch", "karl-marx-ch"],
+// "cloud-heroes/sales-emea": ["karl-marx-ch"]}. As on GitHub, the members
+// of nested teams are members of their parent teams as well.
+func ParseGitHubTeams(teamsString string) (Map, error) {
+	export, exportError := parseGitHubTeamsExport(teamsString)
+	if exportError != nil {
+		return nil, exportError
+	}
+
+	children := map[string][]string{}
+	for _, team := range export.Teams {
+		if team.Parent != nil {
+			children[team.Parent.Slug] = append(children[team.Parent.Slug], team.Slug)
+		}
+	}
+
+	var collectMembers func(slug string, visited map[string]bool) []string
+	collectMembers = func(slug string, visited map[string]bool) []string {
+		visited[slug] = true
+		members := []string{}
+		for _, user := range export.Members[slug] {
+			members = union(members, []string{strings.TrimPrefix(user.Login, "@")})
+		}
+		for _, child := range slices.Sorted(slices.Values(children[slug])) {
+			if !visited[child] {
+				members = union(members, collectMembers(child, visited))
+			}
+		}
+		return members
+	}
+
+	teamMap := Map{}
+	for _, team := range export.Teams {
+		teamMap[team.name()] = collectMembers(team.Slug, map[string]bool{})
+	}
+	for team, members := range teamMap {
+		if error := validateMembers(team, members); error != nil {
+			return nil, error
+		}
+	}
+	return teamMap, nil
+}
@@ -0,0 +1,69 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const gitHubTeamsExportJSON = `{
+  "teams": [
+    {"id": 1, "slug": "sales", "html_url": "https://github.com/orgs/cloud-heroes/teams/sales", "parent": null},
+    {"id": 2, "slug": "sales-emea", "html_url": "https://github.com/orgs/cloud-heroes/teams/sales-emea", "parent": {"id": 1, "slug": "sales"}},
+    {"id": 3, "slug": "sales-emea-nl", "organization": {"login": "cloud-heroes"}, "parent": {"id": 2, "slug": "sales-emea"}},
+    {"id": 4, "slug": "ux"}
+  ],
+  "members": {
+    "sales": [{"login": "jane-doe-ch", "id": 11}],
+    "sales-emea": [{"login": "karl-marx-ch"}, {"login": "jane-doe-ch"}],
+    "sales-emea-nl": [{"login": "gregory"}]
+  }
+}`
+
+func TestParseGitHubTeams(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("teams with their members and the members of their nested teams", func(t *testing.T) {
+		teamMap, error := ParseGitHubTeams(gitHubTeamsExportJSON)
+
+		assert.Nil(error)
+		assert.Equal(Map{
+			"cloud-heroes/sales":         {"jane-doe-ch", "karl-marx-ch", "gregory"},
+			"cloud-heroes/sales-emea":    {"karl-marx-ch", "jane-doe-ch", "gregory"},
+			"cloud-heroes/sales-emea-nl": {"gregory"},
+			"ux":                         {},
+		}, teamMap)
+	})
+
+	t.Run("error: a team map", func(t *testing.T) {
+		_, error := ParseGitHubTeams(`{"ch/sales": ["jane"]}`)
+
+		assert.NotNil(error)
+		assert.Equal("not a GitHub teams export; that's an object with 'teams' (each with a 'slug') and their 'members'", error.Error())
+	})
+
+	t.Run("an export without teams", func(t *testing.T) {
+		teamMap, error := ParseGitHubTeams(`{"teams": []}`)
+
+		assert.Nil(error)
+		assert.Equal(Map{}, teamMap)
+	})
+
+	t.Run("error: teams without a slug", func(t *testing.T) {
+		_, error := ParseGitHubTeams(`{"teams": [{"name": "jane"}]}`)
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: an export with other keys", func(t *testing.T) {
+		_, error := ParseGitHubTeams(`{"teams": [{"slug": "sales"}], "ch/ux": ["davy"]}`)
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: not JSON", func(t *testing.T) {
+		_, error := ParseGitHubTeams(`{"teams": [`)
+
+		assert.NotNil(error)
+	})
+}
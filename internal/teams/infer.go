@@ -0,0 +1,253 @@
+package teams
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// InferOptions influence which virtual teams Infer proposes
+type InferOptions struct {
+	// Owner sets need to occur on at least this many lines to become a
+	// team. Default: 2
+	MinOccurrences int
+	// Goes in front of the names of the teams, e.g. "ch/". Default: "team/"
+	TeamPrefix string
+}
+
+func hasOwners(line codeowners.Line) bool {
+	return (line.Type == "rule" || line.Type == "section-heading") && len(line.Owners) > 0
+}
+
+// getOwnerSet returns the names of the valid owners of a line, sorted and
+// without duplicates
+func getOwnerSet(line codeowners.Line) []string {
+	var ownerSet []string
+	for _, owner := range line.Owners {
+		if owner.Type == "user-or-group" || owner.Type == "e-mail" {
+			ownerSet = append(ownerSet, owner.Name)
+		}
+	}
+	slices.Sort(ownerSet)
+	return slices.Compact(ownerSet)
+}
+
+func isSubset(subset []string, set []string) bool {
+	for _, item := range subset {
+		if !slices.Contains(set, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// candidate is an owner set that occurs on enough lines to become a team
+type candidate struct {
+	key      string
+	owners   []string
+	patterns []string
+}
+
+// getCandidates returns the owner sets of at least two owners that occur on
+// enough lines (on their own or along with other owners), the ones
+// covering the most owner mentions first
+func getCandidates(cst codeowners.CST, minOccurrences int) []candidate {
+	ownerSets := map[string][]string{}
+	for _, line := range cst {
+		if ownerSet := getOwnerSet(line); hasOwners(line) && len(ownerSet) >= 2 {
+			ownerSets[strings.Join(ownerSet, " ")] = ownerSet
+		}
+	}
+
+	var candidates []candidate
+	for _, key := range slices.Sorted(maps.Keys(ownerSets)) {
+		candidate := candidate{key: key, owners: ownerSets[key]}
+		for _, line := range cst {
+			if !hasOwners(line) || !isSubset(candidate.owners, getOwnerSet(line)) {
+				continue
+			}
+			pattern := line.RulePattern
+			if line.Type == "section-heading" {
+				pattern = line.SectionName
+			}
+			candidate.patterns = append(candidate.patterns, pattern)
+		}
+		if len(candidate.patterns) >= minOccurrences {
+			candidates = append(candidates, candidate)
+		}
+	}
+	slices.SortStableFunc(candidates, func(left candidate, right candidate) int {
+		return len(right.owners)*len(right.patterns) - len(left.owners)*len(left.patterns)
+	})
+	return candidates
+}
+
+// getNameFromPatterns returns the most common meaningful last segment of
+// the patterns, e.g. "sales" for "libs/sales/" and "/apps/sales/**"
+func getNameFromPatterns(patterns []string) string {
+	counts := map[string]int{}
+	var names []string
+
+	for _, pattern := range patterns {
+		segments := strings.FieldsFunc(pattern, func(character rune) bool {
+			return character == '/'
+		})
+		for i := len(segments) - 1; i >= 0; i-- {
+			name := strings.TrimSuffix(strings.ToLower(segments[i]), path.Ext(segments[i]))
+			name = strings.Trim(strings.Map(func(character rune) rune {
+				if (character >= 'a' && character <= 'z') || (character >= '0' && character <= '9') || character == '-' || character == '_' {
+					return character
+				}
+				return '-'
+			}, name), "-_")
+			if name != "" {
+				if counts[name] == 0 {
+					names = append(names, name)
+				}
+				counts[name]++
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	slices.SortStableFunc(names, func(left string, right string) int {
+		return counts[right] - counts[left]
+	})
+	return names[0]
+}
+
+// nameTeams gives each candidate a team name, based on the patterns it
+// occurs on. Names that are taken (by other teams or by owners) get a
+// number.
+func nameTeams(candidates []candidate, prefix string, cst codeowners.CST) map[string]string {
+	names := map[string]string{}
+	taken := map[string]bool{}
+	for _, line := range cst {
+		for _, owner := range line.Owners {
+			taken[strings.TrimPrefix(owner.Name, "@")] = true
+		}
+	}
+
+	for i, candidate := range candidates {
+		baseName := getNameFromPatterns(candidate.patterns)
+		if baseName == "" {
+			baseName = fmt.Sprintf("team-%d", i+1)
+		}
+		name := prefix + baseName
+		for suffix := 2; taken[name]; suffix++ {
+			name = fmt.Sprintf("%s%s-%d", prefix, baseName, suffix)
+		}
+		taken[name] = true
+		names[candidate.key] = name
+	}
+	return names
+}
+
+// replaceOwners replaces the owners that are in one of the teams with the
+// team, at the place of the first of its members. The largest teams go
+// first; teams only replace owners no other team replaced.
+func replaceOwners(line codeowners.Line, candidates []candidate, teamNames map[string]string) []codeowners.Owner {
+	remaining := getOwnerSet(line)
+	replacedBy := map[string]string{}
+
+	for _, candidate := range candidates {
+		if isSubset(candidate.owners, remaining) {
+			for _, owner := range candidate.owners {
+				replacedBy[owner] = teamNames[candidate.key]
+			}
+			remaining = slices.DeleteFunc(remaining, func(owner string) bool {
+				return slices.Contains(candidate.owners, owner)
+			})
+		}
+	}
+
+	var owners []codeowners.Owner
+	emitted := map[string]bool{}
+	for _, owner := range line.Owners {
+		team, found := replacedBy[owner.Name]
+		if !found {
+			owners = append(owners, owner)
+			continue
+		}
+		if !emitted[team] {
+			emitted[team] = true
+			owners = append(owners, codeowners.Owner{Type: "user-or-group", Name: "@" + team})
+		}
+	}
+	return owners
+}
+
+// Infer proposes virtual teams for the owner sets that recur in a CST of an
+// existing CODEOWNERS file, and returns the CST with the members of those
+// teams replaced by the team, along with the team map. Teams are named
+// after the paths they own, e.g. "team/sales" for a set of owners that owns
+// "libs/sales/" and "apps/sales/".
+func Infer(cst codeowners.CST, options InferOptions) (codeowners.CST, Map) {
+	minOccurrences := options.MinOccurrences
+	if minOccurrences <= 0 {
+		minOccurrences = 2
+	}
+	prefix := options.TeamPrefix
+	if prefix == "" {
+		prefix = "team/"
+	}
+	candidates := getCandidates(cst, minOccurrences)
+	teamNames := nameTeams(candidates, prefix, cst)
+
+	teamMap := Map{}
+	for _, candidate := range candidates {
+		var members []string
+		for _, owner := range candidate.owners {
+			members = append(members, strings.TrimPrefix(owner, "@"))
+		}
+		teamMap[teamNames[candidate.key]] = members
+	}
+
+	virtualCST := codeowners.CST{}
+	for _, line := range cst {
+		if hasOwners(line) {
+			line.Owners = replaceOwners(line, candidates, teamNames)
+		}
+		virtualCST = append(virtualCST, line)
+	}
+	return virtualCST, teamMap
+}
+
+// CheckEquivalent returns an error when the CSTs don't assign the same
+// owners to the same patterns, in the same order. The order of the owners
+// on a line doesn't matter.
+func CheckEquivalent(expected codeowners.CST, actual codeowners.CST) error {
+	var expectedLines, actualLines []codeowners.Line
+	for _, line := range expected {
+		if line.Type == "rule" || line.Type == "section-heading" {
+			expectedLines = append(expectedLines, line)
+		}
+	}
+	for _, line := range actual {
+		if line.Type == "rule" || line.Type == "section-heading" {
+			actualLines = append(actualLines, line)
+		}
+	}
+	if len(expectedLines) != len(actualLines) {
+		return fmt.Errorf("expected %d rules and sections, found %d", len(expectedLines), len(actualLines))
+	}
+	for i, expectedLine := range expectedLines {
+		actualLine := actualLines[i]
+		if expectedLine.RulePattern != actualLine.RulePattern || expectedLine.SectionName != actualLine.SectionName {
+			return fmt.Errorf("line %d: expected \"%s\", found \"%s\"", expectedLine.LineNo, expectedLine.Raw, actualLine.String())
+		}
+		if !slices.Equal(getOwnerSet(expectedLine), getOwnerSet(actualLine)) {
+			return fmt.Errorf(
+				"line %d: expected owners %s, found %s",
+				expectedLine.LineNo, strings.Join(getOwnerSet(expectedLine), " "), strings.Join(getOwnerSet(actualLine), " "),
+			)
+		}
+	}
+	return nil
+}
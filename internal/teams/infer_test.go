@@ -0,0 +1,92 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+const existingCodeOwners = `# catch-all
+* @jane @karl @davy @john
+
+[Sales]
+libs/sales/ @karl @jane
+apps/sales/ @jane @karl # the app
+libs/sales/refunds/ @jane @karl @gregory
+libs/ux/ @davy @john
+libs/ux/sales-dashboard/ @john @davy @team/sales
+docs/ @davy
+`
+
+func TestInfer(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("proposes teams for recurring owner sets and uses them", func(t *testing.T) {
+		cst, _ := codeowners.Parse(existingCodeOwners)
+		virtualCST, teamMap := Infer(cst, InferOptions{})
+		formatted, _ := virtualCST.Format("")
+
+		assert.Equal(Map{
+			"team/sales-2": {"jane", "karl"},
+			"team/ux":      {"davy", "john"},
+		}, teamMap)
+		assert.Equal(`# catch-all
+* @team/sales-2 @team/ux
+
+[Sales]
+libs/sales/ @team/sales-2
+apps/sales/ @team/sales-2 # the app
+libs/sales/refunds/ @team/sales-2 @gregory
+libs/ux/ @team/ux
+libs/ux/sales-dashboard/ @team/ux @team/sales
+docs/ @davy
+
+`, formatted)
+	})
+
+	t.Run("regenerates to an equivalent CST", func(t *testing.T) {
+		cst, _ := codeowners.Parse(existingCodeOwners)
+		virtualCST, teamMap := Infer(cst, InferOptions{TeamPrefix: "ch/"})
+
+		assert.Nil(CheckEquivalent(cst, Apply(virtualCST, teamMap)))
+	})
+
+	t.Run("respects the minimum number of occurrences", func(t *testing.T) {
+		cst, _ := codeowners.Parse(existingCodeOwners)
+		_, teamMap := Infer(cst, InferOptions{MinOccurrences: 4, TeamPrefix: "ch/"})
+
+		assert.Equal(Map{"ch/sales": {"jane", "karl"}}, teamMap)
+	})
+}
+
+func TestCheckEquivalent(t *testing.T) {
+	assert := assert.New(t)
+
+	expected, _ := codeowners.Parse("* @jane @karl\nlibs/ @davy\n")
+
+	t.Run("owner order doesn't matter", func(t *testing.T) {
+		actual, _ := codeowners.Parse("# a comment\n* @karl @jane\nlibs/ @davy\n")
+
+		assert.Nil(CheckEquivalent(expected, actual))
+	})
+
+	t.Run("error: other owners", func(t *testing.T) {
+		actual, _ := codeowners.Parse("* @karl\nlibs/ @davy\n")
+
+		assert.NotNil(CheckEquivalent(expected, actual))
+		assert.Equal("line 1: expected owners @jane @karl, found @karl", CheckEquivalent(expected, actual).Error())
+	})
+
+	t.Run("error: other patterns", func(t *testing.T) {
+		actual, _ := codeowners.Parse("* @jane @karl\napps/ @davy\n")
+
+		assert.Equal("line 2: expected \"libs/ @davy\", found \"apps/ @davy\n\"", CheckEquivalent(expected, actual).Error())
+	})
+
+	t.Run("error: other number of rules", func(t *testing.T) {
+		actual, _ := codeowners.Parse("* @jane @karl\n")
+
+		assert.Equal("expected 2 rules and sections, found 1", CheckEquivalent(expected, actual).Error())
+	})
+}
@@ -0,0 +1,243 @@
+package teams
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// LDIFOptions tell ParseLDIF which groups to turn into virtual teams and
+// how to find the handles of their members
+type LDIFOptions struct {
+	// Groups with a DN that matches this pattern become virtual teams.
+	// Default: DefaultLDIFGroupPattern
+	GroupPattern *regexp.Regexp
+	// The name of the virtual team, with $1, $2, ... or ${name} replaced by
+	// what the groups in the GroupPattern matched. Default: "$1"
+	TeamName string
+	// The attribute of users that holds their handle, e.g. githubUsername.
+	// Users without one are listed by their e-mail address (the 'mail'
+	// attribute), when they have one.
+	HandleAttribute string
+}
+
+// DefaultLDIFGroupPattern matches all groups, and makes their common name
+// the name of the team
+var DefaultLDIFGroupPattern = regexp.MustCompile(`(?i)^cn=([^,]+)`)
+
+// ldifEntry is an entry in an LDIF file: its DN and its attributes (with
+// lower case names)
+type ldifEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+func (entry ldifEntry) first(attribute string) string {
+	if values := entry.Attributes[strings.ToLower(attribute)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (entry ldifEntry) isGroup() bool {
+	return slices.ContainsFunc(entry.Attributes["objectclass"], func(objectClass string) bool {
+		return slices.Contains(
+			[]string{"group", "groupofnames", "groupofuniquenames", "posixgroup"},
+			strings.ToLower(objectClass),
+		)
+	})
+}
+
+// normalizeDN makes DNs that only differ in case or in spaces around the
+// separators compare equal
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		name, value, _ := strings.Cut(part, "=")
+		parts[i] = strings.TrimSpace(name) + "=" + strings.TrimSpace(value)
+	}
+	return strings.ToLower(strings.Join(parts, ","))
+}
+
+// unfoldLDIF joins the lines that continue on the next one (those start
+// with a space) and drops comments
+func unfoldLDIF(ldif string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(strings.ReplaceAll(ldif, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, " ") && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseLDIFEntries parses the entries in an LDIF file. It doesn't support
+// change records or values read from URLs, as directory exports don't
+// contain those.
+func parseLDIFEntries(ldif string) ([]ldifEntry, error) {
+	var entries []ldifEntry
+	var current *ldifEntry
+
+	for _, line := range unfoldLDIF(ldif) {
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid LDIF line '%s'; attributes look like 'name: value'", line)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if strings.HasPrefix(value, ":") {
+			decoded, error := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+			if error != nil {
+				return nil, fmt.Errorf("invalid base64 value for '%s': %w", name, error)
+			}
+			value = string(decoded)
+		} else if strings.HasPrefix(value, "<") {
+			return nil, fmt.Errorf("values read from a URL aren't supported ('%s')", line)
+		} else {
+			value = strings.TrimSpace(value)
+		}
+
+		if current == nil {
+			if name == "version" {
+				continue
+			}
+			if name != "dn" {
+				return nil, fmt.Errorf("LDIF entries should start with a 'dn'; this one starts with '%s'", line)
+			}
+			entries = append(entries, ldifEntry{DN: value, Attributes: map[string][]string{}})
+			current = &entries[len(entries)-1]
+			continue
+		}
+		current.Attributes[name] = append(current.Attributes[name], value)
+	}
+	return entries, nil
+}
+
+// ParseLDIF reads the groups in an LDIF export of a directory (e.g. LDAP
+// or Active Directory) that match the GroupPattern into a team map, e.g.
+// with GroupPattern "^cn=(sales|after-sales),ou=teams," and TeamName
+// "ch/$1":
+//
+//	dn: cn=sales,ou=teams,dc=example,dc=com
+//	objectClass: groupOfNames
+//	member: uid=jane,ou=people,dc=example,dc=com
+//
+//	dn: uid=jane,ou=people,dc=example,dc=com
+//	objectClass: inetOrgPerson
+//	githubUsername: jane-doe-ch
+//
+// becomes {"ch/sales": ["jane-doe-ch"]}. Members of nested groups are
+// members of the team as well. It warns about members it can't find a
+// handle or e-mail address for, and leaves them out.
+func ParseLDIF(ldif string, options LDIFOptions) (Map, codeowners.Anomalies, error) {
+	entries, error := parseLDIFEntries(ldif)
+	if error != nil {
+		return nil, nil, error
+	}
+	groupPattern := options.GroupPattern
+	if groupPattern == nil {
+		groupPattern = DefaultLDIFGroupPattern
+	}
+	teamName := options.TeamName
+	if teamName == "" {
+		teamName = "$1"
+	}
+	entriesByDN := map[string]ldifEntry{}
+	entriesByUID := map[string]ldifEntry{}
+	for _, entry := range entries {
+		entriesByDN[normalizeDN(entry.DN)] = entry
+		if uid := entry.first("uid"); uid != "" && !entry.isGroup() {
+			entriesByUID[uid] = entry
+		}
+	}
+
+	teamMap := Map{}
+	var warnings codeowners.Anomalies
+	warned := map[string]bool{}
+
+	var collectMembers func(group ldifEntry, team string, visited map[string]bool) []string
+	collectMembers = func(group ldifEntry, team string, visited map[string]bool) []string {
+		var members []string
+		visited[normalizeDN(group.DN)] = true
+
+		var users []ldifEntry
+		var nestedGroups []ldifEntry
+		for _, memberDN := range append(slices.Clone(group.Attributes["member"]), group.Attributes["uniquemember"]...) {
+			member, found := entriesByDN[normalizeDN(memberDN)]
+			if !found {
+				member = ldifEntry{DN: memberDN}
+			}
+			if member.isGroup() {
+				nestedGroups = append(nestedGroups, member)
+				continue
+			}
+			users = append(users, member)
+		}
+		for _, uid := range group.Attributes["memberuid"] {
+			member, found := entriesByUID[uid]
+			if !found {
+				member = ldifEntry{DN: "uid=" + uid}
+			}
+			users = append(users, member)
+		}
+
+		for _, user := range users {
+			name := strings.TrimPrefix(user.first(options.HandleAttribute), "@")
+			if name == "" {
+				name = user.first("mail")
+			}
+			if name == "" {
+				if !warned[team+"\n"+user.DN] {
+					warned[team+"\n"+user.DN] = true
+					warnings = append(warnings, codeowners.Anomaly{
+						Reason: fmt.Sprintf("No '%s' or 'mail' for a member of team '%s'; leaving them out", options.HandleAttribute, team),
+						Raw:    user.DN,
+					})
+				}
+				continue
+			}
+			members = union(members, []string{name})
+		}
+		for _, nestedGroup := range nestedGroups {
+			if !visited[normalizeDN(nestedGroup.DN)] {
+				members = union(members, collectMembers(nestedGroup, team, visited))
+			}
+		}
+		return members
+	}
+
+	for _, entry := range entries {
+		if !entry.isGroup() {
+			continue
+		}
+		match := groupPattern.FindStringSubmatchIndex(entry.DN)
+		if match == nil {
+			continue
+		}
+		team := string(groupPattern.ExpandString(nil, teamName, entry.DN, match))
+		teamMap[team] = union(teamMap[team], collectMembers(entry, team, map[string]bool{}))
+		if teamMap[team] == nil {
+			teamMap[team] = []string{}
+		}
+	}
+
+	for team, members := range teamMap {
+		if error := validateMembers(team, members); error != nil {
+			return nil, nil, error
+		}
+	}
+	return teamMap, warnings, nil
+}
@@ -0,0 +1,97 @@
+package teams
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+const ldifExport = `version: 1
+
+# teams
+dn: CN=Sales,OU=Teams,DC=example,DC=com
+objectClass: top
+objectClass: group
+member: CN=Jane Doe,OU=People,DC=example,DC=com
+member: cn=karl marx, ou=people, dc=example, dc=com
+member: CN=Sales EMEA,OU=Teams,DC=example,DC=com
+
+dn: CN=Sales EMEA,OU=Teams,DC=example,DC=com
+objectClass: group
+member: CN=Gregory,OU=People,DC=example,DC=com
+member: CN=Sales,OU=Teams,DC=example,DC=com
+
+dn: cn=ux,ou=teams,dc=example,dc=com
+objectClass: posixGroup
+memberUid: davy
+memberUid: ghost
+
+dn: CN=Printers,OU=Equipment,DC=example,DC=com
+objectClass: group
+member: CN=Jane Doe,OU=People,DC=example,DC=com
+
+# people
+dn: CN=Jane Doe,OU=People,DC=example,DC=com
+objectClass: user
+githubUsername: jane-doe-ch
+
+dn: CN=Karl Marx,OU=People,DC=example,DC=com
+objectClass: user
+mail: karl@example.com
+
+dn: CN=Gregory,OU=People,DC=example,DC=com
+objectClass: user
+githubUsername:: QGdyZWdvcnk=
+
+dn: uid=davy,ou=people,dc=example,dc=com
+objectClass: inetOrgPerson
+uid: davy
+githubUs
+ ername: davy-davidson
+`
+
+func TestParseLDIF(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("maps matching groups to teams and their members to handles", func(t *testing.T) {
+		teamMap, warnings, error := ParseLDIF(ldifExport, LDIFOptions{
+			GroupPattern:    regexp.MustCompile(`(?i)^cn=([^,]+),ou=teams,`),
+			TeamName:        "ch/$1",
+			HandleAttribute: "githubUsername",
+		})
+
+		assert.Nil(error)
+		assert.Equal(Map{
+			"ch/Sales":      {"jane-doe-ch", "karl@example.com", "gregory"},
+			"ch/Sales EMEA": {"gregory", "jane-doe-ch", "karl@example.com"},
+			"ch/ux":         {"davy-davidson"},
+		}, teamMap)
+		assert.Equal(codeowners.Anomalies{
+			{Reason: "No 'githubUsername' or 'mail' for a member of team 'ch/ux'; leaving them out", Raw: "uid=ghost"},
+		}, warnings)
+	})
+
+	t.Run("by default all groups become teams, named after their common name", func(t *testing.T) {
+		teamMap, _, error := ParseLDIF(ldifExport, LDIFOptions{HandleAttribute: "githubUsername"})
+
+		assert.Nil(error)
+		assert.Equal([]string{"jane-doe-ch"}, teamMap["Printers"])
+		assert.Len(teamMap, 4)
+	})
+
+	t.Run("error: entry without dn", func(t *testing.T) {
+		_, _, error := ParseLDIF("objectClass: group\n", LDIFOptions{})
+
+		assert.NotNil(error)
+		assert.Equal("LDIF entries should start with a 'dn'; this one starts with 'objectClass: group'", error.Error())
+	})
+
+	t.Run("error: not LDIF", func(t *testing.T) {
+		_, _, error := ParseLDIF("dn: cn=sales\nthis isn't ldif\n", LDIFOptions{})
+
+		assert.NotNil(error)
+		assert.Equal("invalid LDIF line 'this isn't ldif'; attributes look like 'name: value'", error.Error())
+	})
+}
@@ -0,0 +1,183 @@
+package teams
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+const dateLayout = "2006-01-02"
+
+// Window is the period in which someone is a member of a team. A zero From
+// or Until means the window is open on that side.
+type Window struct {
+	From  time.Time
+	Until time.Time
+}
+
+// member is how members appear in a team map: either just a name, or an
+// object with a name and the dates the membership starts and/ or ends, e.g.
+// {"name": "jane-doe-ch", "from": "2026-01-01", "until": "2026-06-30"}
+type member struct {
+	Name  string `json:"name"`
+	From  string `json:"from,omitempty"`
+	Until string `json:"until,omitempty"`
+}
+
+func (m *member) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		type plainMember member
+		return json.Unmarshal(data, (*plainMember)(m))
+	}
+	return json.Unmarshal(data, &m.Name)
+}
+
+func (m member) MarshalJSON() ([]byte, error) {
+	if m.From == "" && m.Until == "" {
+		return json.Marshal(m.Name)
+	}
+	type plainMember member
+	return json.Marshal(plainMember(m))
+}
+
+func parseDate(team string, name string, date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	parsedDate, error := time.Parse(dateLayout, date)
+	if error != nil {
+		return time.Time{}, fmt.Errorf("dates should look like YYYY-MM-DD; '%s' (team '%s', member '%s')", date, team, name)
+	}
+	return parsedDate, nil
+}
+
+// getNamesAndWindows returns the names of the members and the membership
+// windows of those that have one
+func getNamesAndWindows(team string, members []member) ([]string, map[string]Window, error) {
+	if members == nil {
+		return nil, nil, nil
+	}
+	names := []string{}
+	windows := map[string]Window{}
+
+	for _, member := range members {
+		names = append(names, member.Name)
+
+		from, error := parseDate(team, member.Name, member.From)
+		if error != nil {
+			return nil, nil, error
+		}
+		until, error := parseDate(team, member.Name, member.Until)
+		if error != nil {
+			return nil, nil, error
+		}
+		if !from.IsZero() || !until.IsZero() {
+			windows[member.Name] = Window{From: from, Until: until}
+		}
+	}
+	if len(windows) == 0 {
+		windows = nil
+	}
+	return names, windows, nil
+}
+
+// isActive returns true when the date is within the window (both ends
+// included)
+func (window Window) isActive(date time.Time) bool {
+	day := date.Format(dateLayout)
+
+	return (window.From.IsZero() || window.From.Format(dateLayout) <= day) &&
+		(window.Until.IsZero() || window.Until.Format(dateLayout) >= day)
+}
+
+func filterActive(members []string, windows map[string]Window, date time.Time) []string {
+	if members == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(members), func(member string) bool {
+		window, found := windows[member]
+		return found && !window.isActive(date)
+	})
+}
+
+// UnchangedPeriod returns the period around the date in which no membership
+// starts or ends, so ActiveOn returns the same for each day in it: from the
+// last day a membership started or ended on or before the date, until the
+// first day one starts or ends after it (exclusive). A zero from or until
+// means the period is open on that side.
+func UnchangedPeriod(settings Settings, date time.Time) (from time.Time, until time.Time) {
+	day := date.Format(dateLayout)
+
+	for _, teamSettings := range settings {
+		for _, window := range teamSettings.Windows {
+			var changes []time.Time
+			if !window.From.IsZero() {
+				changes = append(changes, window.From)
+			}
+			if !window.Until.IsZero() {
+				changes = append(changes, window.Until.AddDate(0, 0, 1))
+			}
+			for _, change := range changes {
+				if change.Format(dateLayout) <= day {
+					if from.IsZero() || change.After(from) {
+						from = change
+					}
+				} else if until.IsZero() || change.Before(until) {
+					until = change
+				}
+			}
+		}
+	}
+	return from, until
+}
+
+// ActiveOn returns the team map and settings with only the members that
+// are active on the given date. It warns about members whose membership
+// has ended, so they can be removed from the team map (mentioning the
+// source(s) of the membership, when known).
+func ActiveOn(teamMap Map, settings Settings, date time.Time) (Map, Settings, codeowners.Anomalies) {
+	activeTeamMap := Map{}
+	activeSettings := Settings{}
+	var warnings codeowners.Anomalies
+
+	teamNames := []string{}
+	for team := range teamMap {
+		teamNames = append(teamNames, team)
+	}
+	slices.Sort(teamNames)
+
+	for _, team := range teamNames {
+		windows := settings[team].Windows
+
+		activeTeamMap[team] = filterActive(teamMap[team], windows, date)
+		for _, member := range teamMap[team] {
+			if window, found := windows[member]; found && !window.Until.IsZero() && window.Until.Format(dateLayout) < date.Format(dateLayout) {
+				reason := fmt.Sprintf("Membership of team '%s' ended on %s", team, window.Until.Format(dateLayout))
+				if sources := settings[team].Provenance[member]; len(sources) > 0 {
+					reason += fmt.Sprintf(" (in '%s')", strings.Join(sources, "', '"))
+				}
+				warnings = append(warnings, codeowners.Anomaly{
+					Reason: reason,
+					Raw:    member,
+				})
+			}
+		}
+	}
+	for team, teamSettings := range settings {
+		teamSettings.ExtraMembers = filterActive(teamSettings.ExtraMembers, teamSettings.Windows, date)
+		teamSettings.Maintainers = filterActive(teamSettings.Maintainers, teamSettings.Windows, date)
+		if teamSettings.Roles != nil {
+			activeRoles := map[string][]string{}
+			for role, roleMembers := range teamSettings.Roles {
+				activeRoles[role] = filterActive(roleMembers, teamSettings.Windows, date)
+			}
+			teamSettings.Roles = activeRoles
+		}
+		activeSettings[team] = teamSettings
+	}
+	return activeTeamMap, activeSettings, warnings
+}
@@ -0,0 +1,123 @@
+package teams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func TestParseTeamMapWithMemberships(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("members with from and until dates", func(t *testing.T) {
+		teamMapString := `{
+			"team1": ["user1", {"name": "user2", "from": "2026-01-01"}, {"name": "user3", "until": "2026-06-30"}],
+			"team2": {"members": [{"name": "user4"}], "maintainers": ["user4"]}
+		}`
+		teamMap, settings, error := ParseWithSettings(teamMapString)
+
+		assert.Nil(error)
+		assert.Equal(Map{
+			"team1": {"user1", "user2", "user3"},
+			"team2": {"user4"},
+		}, teamMap)
+		assert.Equal(Settings{
+			"team1": {Windows: map[string]Window{
+				"user2": {From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+				"user3": {Until: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)},
+			}},
+			"team2": {Maintainers: []string{"user4"}},
+		}, settings)
+	})
+
+	t.Run("error: invalid date", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": [{"name": "user1", "until": "30-06-2026"}]}`)
+
+		assert.NotNil(error)
+		assert.Equal("dates should look like YYYY-MM-DD; '30-06-2026' (team 'team1', member 'user1')", error.Error())
+	})
+}
+
+func TestActiveOn(t *testing.T) {
+	assert := assert.New(t)
+
+	teamMap, settings, _ := ParseWithSettings(`{
+		"team1": [
+			"user1",
+			{"name": "user2", "from": "2026-03-01"},
+			{"name": "user3", "until": "2026-03-31"},
+			{"name": "user4", "from": "2026-02-01", "until": "2026-02-28"}
+		],
+		"team2": {
+			"members": ["user5", {"name": "user6", "until": "2026-01-31"}],
+			"maintainers": ["user5", "user6"]
+		}
+	}`)
+
+	t.Run("only keeps members active on the date (inclusive)", func(t *testing.T) {
+		activeTeamMap, activeSettings, _ := ActiveOn(teamMap, settings, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+		assert.Equal(Map{
+			"team1": {"user1", "user2", "user3"},
+			"team2": {"user5"},
+		}, activeTeamMap)
+		assert.Equal([]string{"user5"}, activeSettings["team2"].Maintainers)
+	})
+
+	t.Run("warns about memberships that have ended", func(t *testing.T) {
+		_, _, warnings := ActiveOn(teamMap, settings, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal(codeowners.Anomalies{
+			{Reason: "Membership of team 'team1' ended on 2026-02-28", Raw: "user4"},
+			{Reason: "Membership of team 'team2' ended on 2026-01-31", Raw: "user6"},
+		}, warnings)
+	})
+
+	t.Run("mentions where ended memberships come from, when known", func(t *testing.T) {
+		mergedTeamMap, mergedSettings, _ := Merge([]Source{{Name: "teams.json", Map: teamMap, Settings: settings}}, "error")
+		_, _, warnings := ActiveOn(mergedTeamMap, mergedSettings, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal(codeowners.Anomalies{
+			{Reason: "Membership of team 'team2' ended on 2026-01-31 (in 'teams.json')", Raw: "user6"},
+		}, warnings)
+	})
+
+	t.Run("members that haven't started yet aren't active, but don't trigger a warning", func(t *testing.T) {
+		activeTeamMap, _, warnings := ActiveOn(teamMap, settings, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal([]string{"user1", "user3"}, activeTeamMap["team1"])
+		assert.Equal(0, len(warnings))
+	})
+}
+
+func TestUnchangedPeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	_, settings, _ := ParseWithSettings(`{
+		"team1": [
+			"user1",
+			{"name": "user2", "from": "2026-03-01"},
+			{"name": "user3", "until": "2026-03-31"}
+		]
+	}`)
+
+	t.Run("from the last change on or before the date until the first one after it", func(t *testing.T) {
+		from, until := UnchangedPeriod(settings, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal("2026-03-01", from.Format(dateLayout))
+		assert.Equal("2026-04-01", until.Format(dateLayout))
+	})
+
+	t.Run("open on the sides without changes", func(t *testing.T) {
+		from, until := UnchangedPeriod(settings, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+
+		assert.True(from.IsZero())
+		assert.Equal("2026-03-01", until.Format(dateLayout))
+
+		from, until = UnchangedPeriod(nil, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+		assert.True(from.IsZero())
+		assert.True(until.IsZero())
+	})
+}
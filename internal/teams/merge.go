@@ -0,0 +1,121 @@
+package teams
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Source is a team map and its settings, as read from e.g. a file
+type Source struct {
+	// Name of the source (e.g. the file name), to report where teams and
+	// members come from
+	Name     string
+	Map      Map
+	Settings Settings
+}
+
+func union(left []string, right []string) []string {
+	returnValue := slices.Clone(left)
+	for _, item := range right {
+		if !slices.Contains(returnValue, item) {
+			returnValue = append(returnValue, item)
+		}
+	}
+	return returnValue
+}
+
+func firstNonEmpty(left string, right string) string {
+	if right != "" {
+		return right
+	}
+	return left
+}
+
+// unionTeams merges the settings of the right team into those of the left.
+// Lists get combined; for single values the right one wins when it's set.
+func unionTeams(left Team, right Team) Team {
+	returnValue := left
+
+	returnValue.Handle = firstNonEmpty(left.Handle, right.Handle)
+	returnValue.Fallback = firstNonEmpty(left.Fallback, right.Fallback)
+	if right.ReviewersPerRule > 0 {
+		returnValue.ReviewersPerRule = right.ReviewersPerRule
+	}
+	if right.ExtraMembers != nil {
+		returnValue.ExtraMembers = union(left.ExtraMembers, right.ExtraMembers)
+	}
+	if right.Maintainers != nil {
+		returnValue.Maintainers = union(left.Maintainers, right.Maintainers)
+	}
+	if right.Roles != nil {
+		returnValue.Roles = maps.Clone(left.Roles)
+		if returnValue.Roles == nil {
+			returnValue.Roles = map[string][]string{}
+		}
+		for role, roleMembers := range right.Roles {
+			returnValue.Roles[role] = union(returnValue.Roles[role], roleMembers)
+		}
+	}
+	if right.Windows != nil {
+		returnValue.Windows = maps.Clone(left.Windows)
+		if returnValue.Windows == nil {
+			returnValue.Windows = map[string]Window{}
+		}
+		maps.Copy(returnValue.Windows, right.Windows)
+	}
+	return returnValue
+}
+
+// Merge combines the team maps and settings of the sources into one. What
+// happens when a team is in more than one source depends on the strategy:
+//   - "error" (default) returns an error
+//   - "union" combines the members and settings of the team
+//   - "override" uses the team as defined in the last source that has it
+//
+// The Provenance of each team lists which source(s) each member came from.
+func Merge(sources []Source, strategy string) (Map, Settings, error) {
+	teamMap := Map{}
+	settings := Settings{}
+	definedIn := map[string]string{}
+
+	for _, source := range sources {
+		teamNames := slices.Sorted(maps.Keys(source.Map))
+
+		for _, team := range teamNames {
+			members := source.Map[team]
+			teamSettings := source.Settings[team]
+
+			if previousSource, found := definedIn[team]; found {
+				switch strategy {
+				case "union":
+					members = union(teamMap[team], members)
+					teamSettings = unionTeams(settings[team], teamSettings)
+				case "override":
+					teamSettings.Provenance = nil
+				default:
+					return nil, nil, fmt.Errorf(
+						"team '%s' is defined in both '%s' and '%s'; use a merge strategy of 'union' or 'override' to combine them",
+						team, previousSource, source.Name,
+					)
+				}
+			}
+			definedIn[team] = source.Name
+
+			provenance := map[string][]string{}
+			for _, member := range source.Map[team] {
+				provenance[member] = append(provenance[member], source.Name)
+			}
+			if strategy == "union" {
+				for member, memberSources := range teamSettings.Provenance {
+					provenance[member] = union(memberSources, provenance[member])
+				}
+			}
+			teamSettings.Provenance = provenance
+
+			teamMap[team] = members
+			settings[team] = teamSettings
+		}
+	}
+	return teamMap, settings, nil
+}
@@ -0,0 +1,88 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	orgWide := Source{
+		Name: "org-teams.json",
+		Map: Map{
+			"ch/sales": {"jane", "karl"},
+			"ch/ux":    {"davy"},
+		},
+		Settings: Settings{
+			"ch/sales": {Maintainers: []string{"jane"}, Fallback: "@org/sales"},
+		},
+	}
+	repoLocal := Source{
+		Name: "virtual-teams.json",
+		Map: Map{
+			"ch/sales":   {"karl", "gregory"},
+			"ch/refunds": {"dagny"},
+		},
+		Settings: Settings{
+			"ch/sales": {Maintainers: []string{"gregory"}, ReviewersPerRule: 2},
+		},
+	}
+
+	t.Run("distinct teams are combined & get their provenance", func(t *testing.T) {
+		teamMap, settings, error := Merge([]Source{
+			orgWide,
+			{Name: "local.json", Map: Map{"ch/refunds": {"dagny"}}},
+		}, "error")
+
+		assert.Nil(error)
+		assert.Equal(Map{
+			"ch/sales":   {"jane", "karl"},
+			"ch/ux":      {"davy"},
+			"ch/refunds": {"dagny"},
+		}, teamMap)
+		assert.Equal(map[string][]string{"dagny": {"local.json"}}, settings["ch/refunds"].Provenance)
+		assert.Equal("@org/sales", settings["ch/sales"].Fallback)
+	})
+
+	t.Run("error: a team is defined in more than one source", func(t *testing.T) {
+		_, _, error := Merge([]Source{orgWide, repoLocal}, "error")
+
+		assert.NotNil(error)
+		assert.Equal(
+			"team 'ch/sales' is defined in both 'org-teams.json' and 'virtual-teams.json'; use a merge strategy of 'union' or 'override' to combine them",
+			error.Error(),
+		)
+	})
+
+	t.Run("union combines the members & settings of the team", func(t *testing.T) {
+		teamMap, settings, error := Merge([]Source{orgWide, repoLocal}, "union")
+
+		assert.Nil(error)
+		assert.Equal([]string{"jane", "karl", "gregory"}, teamMap["ch/sales"])
+		assert.Equal([]string{"jane", "gregory"}, settings["ch/sales"].Maintainers)
+		assert.Equal("@org/sales", settings["ch/sales"].Fallback)
+		assert.Equal(2, settings["ch/sales"].ReviewersPerRule)
+		assert.Equal(map[string][]string{
+			"jane":    {"org-teams.json"},
+			"karl":    {"org-teams.json", "virtual-teams.json"},
+			"gregory": {"virtual-teams.json"},
+		}, settings["ch/sales"].Provenance)
+	})
+
+	t.Run("override uses the team from the last source that has it", func(t *testing.T) {
+		teamMap, settings, error := Merge([]Source{orgWide, repoLocal}, "override")
+
+		assert.Nil(error)
+		assert.Equal([]string{"karl", "gregory"}, teamMap["ch/sales"])
+		assert.Equal(Team{
+			Maintainers:      []string{"gregory"},
+			ReviewersPerRule: 2,
+			Provenance: map[string][]string{
+				"karl":    {"virtual-teams.json"},
+				"gregory": {"virtual-teams.json"},
+			},
+		}, settings["ch/sales"])
+	})
+}
@@ -1,7 +1,6 @@
 package teams
 
 import (
-	"cmp"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -10,73 +9,169 @@ import (
 	"github.com/sverweij/vcodeowners/internal/codeowners"
 )
 
+// Map maps the names of virtual teams to their members
 type Map map[string][]string
 
-func Parse(teamMapString string) (Map, error) {
-	var teamMap Map
-	error := json.Unmarshal([]byte(teamMapString), &teamMap)
-	// unmarshal & strong typing already ensure the map is in the right
-	// shape. Only thing left is to check if the usernames don't accidentally
-	// contain the "@" prefix.
-	for team, members := range teamMap {
-		for i, member := range members {
-			if strings.HasPrefix(member, "@") {
-				return nil, fmt.Errorf("don't start team member names with an '@'; '%s' (team '%s', member %d)", member, team, i)
-			}
-		}
-	}
-	return teamMap, error
+// Team holds the settings of a virtual team that go beyond its members
+type Team struct {
+	// A real GitHub/ GitLab team handle (e.g. "@org/real-team") to emit
+	// instead of the members of the virtual team.
+	Handle string `json:"handle,omitempty"`
+	// Members to emit along with the handle, e.g. because they're not in
+	// the real team (yet).
+	ExtraMembers []string `json:"extraMembers,omitempty"`
+	// A real team handle to emit instead of the members when the team is
+	// too large (see Options.LargeTeamThreshold).
+	Fallback string `json:"fallback,omitempty"`
+	// Members to emit instead of all members when the team is too large and
+	// has no fallback handle.
+	Maintainers []string `json:"maintainers,omitempty"`
+	// Other roles within the team and their members. VIRTUAL-CODEOWNERS can
+	// refer to them with a suffix, e.g. @ch/sales:reviewers. Unless declared
+	// here the 'members' role is the whole team and the 'maintainers' role
+	// the Maintainers.
+	Roles map[string][]string `json:"roles,omitempty"`
+	// The number of members to list per rule. Which ones is decided by a
+	// hash of the rule's pattern, so it's stable between runs and spreads
+	// the reviews across the team. 0 means all members.
+	ReviewersPerRule int `json:"reviewersPerRule,omitempty"`
+	// The periods in which members are in the team, for the members that
+	// have one (see ActiveOn).
+	Windows map[string]Window `json:"-"`
+	// The sources (e.g. files) each member comes from, when the team map
+	// was merged from more than one (see Merge).
+	Provenance map[string][]string `json:"-"`
 }
 
-func cookOwner(ownerString string) codeowners.Owner {
-	owner := codeowners.ParseOwner(ownerString)
+// Settings maps the names of virtual teams to their settings
+type Settings map[string]Team
+
+// teamDefinition is the shape of a team in a team map when it's not just
+// a list of members
+type teamDefinition struct {
+	Team
+	Members []member `json:"members"`
+}
 
-	// if an owner in a team map doesn't start with an @, ParseOwner will
-	// classify it as 'invalid'. However, that's how owners should appear in
-	// team maps => chuck an '@' in front of it and call it a regular 'user-or-group'
-	if owner.Type == "invalid" {
-		owner.Type = "user-or-group"
-		owner.Name = "@" + ownerString
+func validateMembers(team string, members []string) error {
+	for i, member := range members {
+		if strings.HasPrefix(member, "@") {
+			return fmt.Errorf("don't start team member names with an '@'; '%s' (team '%s', member %d)", member, team, i)
+		}
+		if codeowners.ParseOwner(member).Type == "e-mail" {
+			continue
+		}
+		if owner := codeowners.ParseOwner("@" + member); owner.Type != "user-or-group" || owner.Role != "" {
+			return fmt.Errorf("invalid team member name '%s' (team '%s', member %d)", member, team, i)
+		}
 	}
+	return nil
+}
 
-	return owner
+func validateHandle(team string, handle string) error {
+	if handle != "" && !strings.HasPrefix(handle, "@") {
+		return fmt.Errorf("start team handles with an '@'; '%s' (team '%s')", handle, team)
+	}
+	return nil
 }
 
-func uniqOwners(owners []codeowners.Owner) []codeowners.Owner {
-	var visited = map[string]bool{}
-	var returnValue []codeowners.Owner
+// Parse parses a team map in JSON format. Teams are either a list of
+// members, or an object with members and settings (see ParseWithSettings).
+func Parse(teamMapString string) (Map, error) {
+	teamMap, _, error := ParseWithSettings(teamMapString)
+	return teamMap, error
+}
 
-	for _, owner := range owners {
-		if !visited[owner.Name] {
-			returnValue = append(returnValue, owner)
-			visited[owner.Name] = true
-		}
+// ParseWithSettings parses a team map in JSON format and returns both the
+// members of each team and the settings of teams that have them, e.g.
+//
+//	{
+//	  "ch/ux": ["davy-davidson-ch", "john-johnson-ch"],
+//	  "ch/sales": {
+//	    "handle": "@cloud-heroes/sales",
+//	    "members": ["jane-doe-ch", "karl-marx-ch"],
+//	    "extraMembers": ["karl-marx-ch"]
+//	  },
+//	  "ch/after-sales": {
+//	    "members": ["john-doe-ch", "daisy-duck", "donald-duck"],
+//	    "fallback": "@cloud-heroes/after-sales",
+//	    "maintainers": ["john-doe-ch"],
+//	    "roles": {"reviewers": ["daisy-duck", "donald-duck"]}
+//	  },
+//	  "ch/platform": {
+//	    "members": ["mary-the-merry-ch", "luke-the-lucky-ch", "koos-koets"],
+//	    "reviewersPerRule": 2
+//	  },
+//	  "ch/reading": [
+//	    "teun",
+//	    {"name": "gijs", "from": "2026-01-01", "until": "2026-06-30"}
+//	  ]
+//	}
+func ParseWithSettings(teamMapString string) (Map, Settings, error) {
+	var rawTeamMap map[string]json.RawMessage
+	error := json.Unmarshal([]byte(teamMapString), &rawTeamMap)
+	if error != nil {
+		return nil, nil, error
 	}
-	return returnValue
-}
+	teamMap := Map{}
+	settings := Settings{}
+
+	for team, rawTeam := range rawTeamMap {
+		var definition teamDefinition
+		isObject := strings.HasPrefix(strings.TrimSpace(string(rawTeam)), "{")
 
-func Apply(lines codeowners.CST, teamMap Map) codeowners.CST {
-	transformedLines := codeowners.CST{}
+		// unmarshal & strong typing already ensure the teams are in the
+		// right shape.
+		if isObject {
+			error = json.Unmarshal(rawTeam, &definition)
+		} else {
+			error = json.Unmarshal(rawTeam, &definition.Members)
+		}
+		if error != nil {
+			return nil, nil, fmt.Errorf("team '%s': %w", team, error)
+		}
+		members, windows, error := getNamesAndWindows(team, definition.Members)
+		if error != nil {
+			return nil, nil, error
+		}
+		if isObject && members == nil {
+			members = []string{}
+		}
+		definition.Windows = windows
+		if isObject || windows != nil {
+			settings[team] = definition.Team
+		}
+		teamMap[team] = members
 
-	for _, line := range lines {
-		if line.Type == "rule" || line.Type == "section-heading" {
-			var newOwners []codeowners.Owner = []codeowners.Owner{}
-			for _, owner := range line.Owners {
-				if members := teamMap[strings.TrimPrefix(owner.Name, "@")]; members != nil && owner.Type == "user-or-group" {
-					for _, member := range members {
-						newOwners = append(newOwners, cookOwner(member))
-					}
-				} else {
-					newOwners = append(newOwners, owner)
+		// Only thing left is to check if the usernames don't accidentally
+		// contain the "@" prefix, and that handles _do_ have it.
+		if error := validateMembers(team, members); error != nil {
+			return nil, nil, error
+		}
+		if error := validateMembers(team, definition.ExtraMembers); error != nil {
+			return nil, nil, error
+		}
+		if error := validateHandle(team, definition.Handle); error != nil {
+			return nil, nil, error
+		}
+		if error := validateHandle(team, definition.Fallback); error != nil {
+			return nil, nil, error
+		}
+		if definition.ReviewersPerRule < 0 {
+			return nil, nil, fmt.Errorf("reviewersPerRule can't be negative; %d (team '%s')", definition.ReviewersPerRule, team)
+		}
+		for _, maintainer := range definition.Maintainers {
+			if !slices.Contains(members, maintainer) {
+				return nil, nil, fmt.Errorf("maintainers should be members of their team; '%s' isn't (team '%s')", maintainer, team)
+			}
+		}
+		for role, roleMembers := range definition.Roles {
+			for _, roleMember := range roleMembers {
+				if !slices.Contains(members, roleMember) {
+					return nil, nil, fmt.Errorf("role members should be members of their team; '%s' isn't (team '%s', role '%s')", roleMember, team, role)
 				}
 			}
-			slices.SortFunc(newOwners, func(a, b codeowners.Owner) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			line.Owners = uniqOwners(newOwners)
 		}
-		transformedLines = append(transformedLines, line)
 	}
-
-	return transformedLines
+	return teamMap, settings, nil
 }
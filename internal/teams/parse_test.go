@@ -4,7 +4,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/sverweij/vcodeowners/internal/codeowners"
 )
 
 func TestParseTeamMap(t *testing.T) {
@@ -41,6 +40,20 @@ func TestParseTeamMap(t *testing.T) {
 		)
 	})
 
+	t.Run("error: team member name with a typo", func(t *testing.T) {
+		_, error := Parse(`{"team1": ["user1", "john_doe."]}`)
+
+		assert.NotNil(error)
+		assert.Equal("invalid team member name 'john_doe.' (team 'team1', member 1)", error.Error())
+	})
+
+	t.Run("error: team member e-mail address without a domain", func(t *testing.T) {
+		_, error := Parse(`{"team1": ["john@localhost"]}`)
+
+		assert.NotNil(error)
+		assert.Equal("invalid team member name 'john@localhost' (team 'team1', member 0)", error.Error())
+	})
+
 	t.Run("error: team map is an array", func(t *testing.T) {
 		teamMapString := `["it's", "a", "trap"]`
 		teamMap, error := Parse(teamMapString)
@@ -52,295 +65,88 @@ func TestParseTeamMap(t *testing.T) {
 	// other validations might follow
 }
 
-func TestApplyTeamMap(t *testing.T) {
+func TestParseTeamMapWithSettings(t *testing.T) {
 	assert := assert.New(t)
-	t.Run("replaces teams in CodeOwners CSTs - rule", func(t *testing.T) {
-		codeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@team2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team1",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		teamMap := Map{
+
+	t.Run("teams with settings", func(t *testing.T) {
+		teamMapString := `{
+			"team1": ["user1", "user2"],
+			"team2": {"handle": "@org/team2", "members": ["user3", "user4"], "extraMembers": ["user4"]},
+			"team3": {"handle": "@org/team3"}
+		}`
+		teamMap, settings, error := ParseWithSettings(teamMapString)
+
+		assert.Nil(error)
+		assert.Equal(Map{
 			"team1": {"user1", "user2"},
-			"team2": {"user3", "user4", "user5@somewhere.else.com"},
-		}
-		expectedCodeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@user1",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user3",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user4",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-					{
-						Type: "e-mail",
-						Name: "user5@somewhere.else.com",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		transformedCodeOwners := Apply(codeOwners, teamMap)
-
-		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+			"team2": {"user3", "user4"},
+			"team3": {},
+		}, teamMap)
+		assert.Equal(Settings{
+			"team2": {Handle: "@org/team2", ExtraMembers: []string{"user4"}},
+			"team3": {Handle: "@org/team3"},
+		}, settings)
 	})
 
-	t.Run("replaces teams in CodeOwners CSTs & uniqs them - rule", func(t *testing.T) {
-		codeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@team2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team1",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		teamMap := Map{
-			"team1": {"user1", "user2", "not@replaced.nl"},
-			"team2": {"user3", "user4", "user2", "user1", "user5@somewhere.else.com"},
-		}
-		expectedCodeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* @team2 @team1 not@replaced.nl # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@user1",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user3",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user4",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-					{
-						Type: "e-mail",
-						Name: "user5@somewhere.else.com",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		transformedCodeOwners := Apply(codeOwners, teamMap)
-
-		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	t.Run("Parse only returns the members", func(t *testing.T) {
+		teamMap, error := Parse(`{"team2": {"handle": "@org/team2", "members": ["user3"]}}`)
+
+		assert.Nil(error)
+		assert.Equal(Map{"team2": {"user3"}}, teamMap)
 	})
 
-	t.Run("replaces teams in CodeOwners CSTs - section-heading", func(t *testing.T) {
-		codeOwners := codeowners.CST{
-			{
-				Type:   "section-heading",
-				LineNo: 1,
-				Raw:    "[some_section] @team2 @team1 not@replaced.nl # comment with @team1",
-
-				SectionOptional:     false,
-				SectionName:         "some_section",
-				SectionMinApprovers: 0,
-				Spaces:              " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@team2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team1",
-					},
-					{
-						Type: "user-or-group",
-						// same as before - not a typo
-						Name: "@team1",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		teamMap := Map{
-			"team1": {"user1", "user2"},
-			"team2": {"user3", "user4", "user5@somewhere.else.com"},
-		}
-		expectedCodeOwners := codeowners.CST{
-			{
-				Type:   "section-heading",
-				LineNo: 1,
-				Raw:    "[some_section] @team2 @team1 not@replaced.nl # comment with @team1",
-
-				SectionOptional:     false,
-				SectionName:         "some_section",
-				SectionMinApprovers: 0,
-				Spaces:              " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@user1",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user3",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@user4",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-					{
-						Type: "e-mail",
-						Name: "user5@somewhere.else.com",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		transformedCodeOwners := Apply(codeOwners, teamMap)
-
-		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	t.Run("error: handle doesn't start with an @", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": {"handle": "org/team1"}}`)
+
+		assert.NotNil(error)
+		assert.Equal("start team handles with an '@'; 'org/team1' (team 'team1')", error.Error())
+	})
+
+	t.Run("error: extra member starts with an @", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": {"handle": "@org/team1", "extraMembers": ["@user1"]}}`)
+
+		assert.NotNil(error)
+		assert.Equal("don't start team member names with an '@'; '@user1' (team 'team1', member 0)", error.Error())
+	})
+
+	t.Run("team with a fallback and maintainers", func(t *testing.T) {
+		_, settings, error := ParseWithSettings(`{"team1": {"members": ["user1", "user2"], "fallback": "@org/team1", "maintainers": ["user2"]}}`)
+
+		assert.Nil(error)
+		assert.Equal(Settings{"team1": {Fallback: "@org/team1", Maintainers: []string{"user2"}}}, settings)
+	})
+
+	t.Run("error: fallback doesn't start with an @", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": {"members": ["user1"], "fallback": "org/team1"}}`)
+
+		assert.NotNil(error)
+		assert.Equal("start team handles with an '@'; 'org/team1' (team 'team1')", error.Error())
+	})
+
+	t.Run("error: maintainer isn't a member", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": {"members": ["user1"], "maintainers": ["user2"]}}`)
+
+		assert.NotNil(error)
+		assert.Equal("maintainers should be members of their team; 'user2' isn't (team 'team1')", error.Error())
 	})
 
-	t.Run("only sorts owners when the team map is empty", func(t *testing.T) {
-		codeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* not@replaced.nl @team2 @team3 @team1 # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team3",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team1",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		teamMap := Map{}
-		expectedCodeOwners := codeowners.CST{
-			{
-				Type:   "rule",
-				LineNo: 1,
-				Raw:    "* not@replaced.nl @team2 @team3 @team1 # comment with @team1",
-
-				RulePattern: "*",
-				Spaces:      " ",
-				Owners: []codeowners.Owner{
-					{
-						Type: "user-or-group",
-						Name: "@team1",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team2",
-					},
-					{
-						Type: "user-or-group",
-						Name: "@team3",
-					},
-					{
-						Type: "e-mail",
-						Name: "not@replaced.nl",
-					},
-				},
-				InlineComment: " comment with @team1",
-			},
-		}
-		transformedCodeOwners := Apply(codeOwners, teamMap)
-
-		assert.Equal(expectedCodeOwners, transformedCodeOwners)
+	t.Run("team with roles", func(t *testing.T) {
+		_, settings, error := ParseWithSettings(`{"team1": {"members": ["user1", "user2"], "roles": {"reviewers": ["user2"]}}}`)
+
+		assert.Nil(error)
+		assert.Equal(Settings{"team1": {Roles: map[string][]string{"reviewers": {"user2"}}}}, settings)
+	})
+
+	t.Run("error: role member isn't a member", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": {"members": ["user1"], "roles": {"reviewers": ["user2"]}}}`)
+
+		assert.NotNil(error)
+		assert.Equal("role members should be members of their team; 'user2' isn't (team 'team1', role 'reviewers')", error.Error())
+	})
+
+	t.Run("error: team is neither a list nor an object", func(t *testing.T) {
+		_, _, error := ParseWithSettings(`{"team1": "user1"}`)
+
+		assert.NotNil(error)
 	})
 }
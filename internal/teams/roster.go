@@ -0,0 +1,205 @@
+package teams
+
+import (
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// RosterColumns maps the fields vcodeowners needs from a roster to the
+// names of the columns in the roster's header row that hold them
+type RosterColumns struct {
+	Team   string
+	Handle string
+	Email  string
+	Role   string
+	Start  string
+	End    string
+}
+
+// DefaultRosterColumns are the column names ParseRoster uses when nothing
+// else is specified
+var DefaultRosterColumns = RosterColumns{
+	Team:   "team",
+	Handle: "handle",
+	Email:  "email",
+	Role:   "role",
+	Start:  "start",
+	End:    "end",
+}
+
+// ParseRosterColumns parses a column mapping like "team=Team,handle=GitHub
+// login" on top of the default column names
+func ParseRosterColumns(mapping string) (RosterColumns, error) {
+	columns := DefaultRosterColumns
+	if strings.TrimSpace(mapping) == "" {
+		return columns, nil
+	}
+	fields := map[string]*string{
+		"team":   &columns.Team,
+		"handle": &columns.Handle,
+		"email":  &columns.Email,
+		"role":   &columns.Role,
+		"start":  &columns.Start,
+		"end":    &columns.End,
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		field, column, found := strings.Cut(pair, "=")
+		target := fields[strings.ToLower(strings.TrimSpace(field))]
+		if !found || target == nil || strings.TrimSpace(column) == "" {
+			return columns, fmt.Errorf(
+				"invalid column mapping '%s'; map team, handle, email, role, start or end to a column, e.g. team=Team,handle=GitHub",
+				pair,
+			)
+		}
+		*target = strings.TrimSpace(column)
+	}
+	return columns, nil
+}
+
+// getColumnIndexes returns the index of each column in the header row
+// (case insensitive), or -1 when the roster doesn't have it
+func getColumnIndexes(header []string, columns RosterColumns) map[string]int {
+	indexes := map[string]int{}
+	for field, column := range map[string]string{
+		"team":   columns.Team,
+		"handle": columns.Handle,
+		"email":  columns.Email,
+		"role":   columns.Role,
+		"start":  columns.Start,
+		"end":    columns.End,
+	} {
+		indexes[field] = slices.IndexFunc(header, func(name string) bool {
+			return strings.EqualFold(strings.TrimSpace(name), column)
+		})
+	}
+	return indexes
+}
+
+// ParseRoster parses a roster in CSV format with a header row, e.g. an
+// export of a spreadsheet people managers keep:
+//
+//	team,handle,email,role,start,end
+//	ch/sales,jane-doe-ch,jane@example.com,maintainer,,
+//	ch/sales,,karl@example.com,,2026-01-01,2026-06-30
+//
+// Members are listed by their handle, or by their e-mail address when they
+// don't have one. The 'maintainer(s)' role makes them a maintainer of the
+// team, other roles (except 'member(s)') become roles of the team. Start
+// and end are the dates their membership starts and ends.
+func ParseRoster(rosterString string, columns RosterColumns) (Map, Settings, error) {
+	reader := csv.NewReader(strings.NewReader(rosterString))
+	reader.FieldsPerRecord = -1
+	records, error := reader.ReadAll()
+	if error != nil {
+		return nil, nil, error
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("the roster is empty; it should at least have a header row")
+	}
+
+	indexes := getColumnIndexes(records[0], columns)
+	if indexes["team"] < 0 {
+		return nil, nil, fmt.Errorf("the roster has no '%s' column", columns.Team)
+	}
+	if indexes["handle"] < 0 && indexes["email"] < 0 {
+		return nil, nil, fmt.Errorf("the roster has no '%s' or '%s' column", columns.Handle, columns.Email)
+	}
+
+	teamMap := Map{}
+	settings := Settings{}
+	for rowNumber, record := range records[1:] {
+		field := func(name string) string {
+			if indexes[name] < 0 || indexes[name] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[indexes[name]])
+		}
+		team := field("team")
+		if team == "" && strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if team == "" {
+			return nil, nil, fmt.Errorf("row %d of the roster has no team", rowNumber+2)
+		}
+		name := strings.TrimPrefix(field("handle"), "@")
+		if name == "" {
+			name = field("email")
+		}
+		if name == "" {
+			return nil, nil, fmt.Errorf("row %d of the roster has no handle or e-mail address (team '%s')", rowNumber+2, team)
+		}
+
+		teamMap[team] = union(teamMap[team], []string{name})
+		teamSettings := settings[team]
+
+		switch role := strings.ToLower(field("role")); role {
+		case "", "member", "members":
+		case "maintainer", "maintainers":
+			teamSettings.Maintainers = union(teamSettings.Maintainers, []string{name})
+		default:
+			if teamSettings.Roles == nil {
+				teamSettings.Roles = map[string][]string{}
+			}
+			teamSettings.Roles[role] = union(teamSettings.Roles[role], []string{name})
+		}
+
+		from, error := parseDate(team, name, field("start"))
+		if error != nil {
+			return nil, nil, error
+		}
+		until, error := parseDate(team, name, field("end"))
+		if error != nil {
+			return nil, nil, error
+		}
+		if !from.IsZero() || !until.IsZero() {
+			if teamSettings.Windows == nil {
+				teamSettings.Windows = map[string]Window{}
+			}
+			teamSettings.Windows[name] = Window{From: from, Until: until}
+		}
+		settings[team] = teamSettings
+	}
+
+	for team, members := range teamMap {
+		if error := validateMembers(team, members); error != nil {
+			return nil, nil, error
+		}
+	}
+	return teamMap, settings, nil
+}
+
+// UpdateFromRoster returns the team map and settings with the members,
+// maintainers, roles and membership windows of the teams in the roster
+// replaced by those in the roster. Other settings of those teams (like
+// their handle) and teams that aren't in the roster stay as they are.
+func UpdateFromRoster(teamMap Map, settings Settings, rosterMap Map, rosterSettings Settings) (Map, Settings) {
+	updatedMap := maps.Clone(teamMap)
+	if updatedMap == nil {
+		updatedMap = Map{}
+	}
+	updatedSettings := maps.Clone(settings)
+	if updatedSettings == nil {
+		updatedSettings = Settings{}
+	}
+
+	for team, members := range rosterMap {
+		teamSettings := updatedSettings[team]
+		teamSettings.Maintainers = rosterSettings[team].Maintainers
+		teamSettings.Roles = rosterSettings[team].Roles
+		teamSettings.Windows = rosterSettings[team].Windows
+		teamSettings.ExtraMembers = slices.DeleteFunc(slices.Clone(teamSettings.ExtraMembers), func(extraMember string) bool {
+			return !slices.Contains(members, extraMember)
+		})
+		if len(teamSettings.ExtraMembers) == 0 {
+			teamSettings.ExtraMembers = nil
+		}
+
+		updatedMap[team] = members
+		updatedSettings[team] = teamSettings
+	}
+	return updatedMap, updatedSettings
+}
@@ -0,0 +1,123 @@
+package teams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRosterColumns(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		columns, error := ParseRosterColumns("")
+
+		assert.Nil(error)
+		assert.Equal(DefaultRosterColumns, columns)
+	})
+
+	t.Run("overrides the columns mentioned", func(t *testing.T) {
+		columns, error := ParseRosterColumns("team=Squad, handle=GitHub login")
+
+		assert.Nil(error)
+		assert.Equal("Squad", columns.Team)
+		assert.Equal("GitHub login", columns.Handle)
+		assert.Equal("email", columns.Email)
+	})
+
+	t.Run("error: unknown field", func(t *testing.T) {
+		_, error := ParseRosterColumns("team=Squad,salary=Pay")
+
+		assert.NotNil(error)
+		assert.Equal(
+			"invalid column mapping 'salary=Pay'; map team, handle, email, role, start or end to a column, e.g. team=Team,handle=GitHub",
+			error.Error(),
+		)
+	})
+}
+
+func TestParseRoster(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("members, roles and windows", func(t *testing.T) {
+		teamMap, settings, error := ParseRoster(
+			"Team,Handle,Email,Role,Start,End\n"+
+				"ch/sales,jane-doe,jane@example.com,Maintainer,,\n"+
+				"ch/sales,,karl@example.com,,2026-01-01,2026-06-30\n"+
+				"ch/sales,@gregory,,reviewer,,\n"+
+				",,,,,\n"+
+				"ch/ux,davy,,,,\n",
+			DefaultRosterColumns,
+		)
+
+		assert.Nil(error)
+		assert.Equal(Map{
+			"ch/sales": {"jane-doe", "karl@example.com", "gregory"},
+			"ch/ux":    {"davy"},
+		}, teamMap)
+		assert.Equal(Settings{
+			"ch/sales": {
+				Maintainers: []string{"jane-doe"},
+				Roles:       map[string][]string{"reviewer": {"gregory"}},
+				Windows: map[string]Window{"karl@example.com": {
+					From:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					Until: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC),
+				}},
+			},
+			"ch/ux": {},
+		}, settings)
+	})
+
+	t.Run("mapped columns; missing optional columns", func(t *testing.T) {
+		columns, _ := ParseRosterColumns("team=Squad,handle=GitHub")
+		teamMap, _, error := ParseRoster("Name,Squad,GitHub\nJane,ch/sales,jane-doe\n", columns)
+
+		assert.Nil(error)
+		assert.Equal(Map{"ch/sales": {"jane-doe"}}, teamMap)
+	})
+
+	t.Run("error: no team column", func(t *testing.T) {
+		_, _, error := ParseRoster("squad,handle\nch/sales,jane\n", DefaultRosterColumns)
+
+		assert.NotNil(error)
+		assert.Equal("the roster has no 'team' column", error.Error())
+	})
+
+	t.Run("error: row without handle or e-mail address", func(t *testing.T) {
+		_, _, error := ParseRoster("team,handle\nch/sales,jane\nch/sales,\n", DefaultRosterColumns)
+
+		assert.NotNil(error)
+		assert.Equal("row 3 of the roster has no handle or e-mail address (team 'ch/sales')", error.Error())
+	})
+
+	t.Run("error: invalid date", func(t *testing.T) {
+		_, _, error := ParseRoster("team,handle,start\nch/sales,jane,01-01-2026\n", DefaultRosterColumns)
+
+		assert.NotNil(error)
+		assert.Equal("dates should look like YYYY-MM-DD; '01-01-2026' (team 'ch/sales', member 'jane')", error.Error())
+	})
+
+	t.Run("error: invalid member name", func(t *testing.T) {
+		_, _, error := ParseRoster("team,handle\nch/sales,jane doe\n", DefaultRosterColumns)
+
+		assert.NotNil(error)
+		assert.Equal("invalid team member name 'jane doe' (team 'ch/sales', member 0)", error.Error())
+	})
+}
+
+func TestUpdateFromRoster(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("replaces members of teams in the roster, keeps other settings and teams", func(t *testing.T) {
+		teamMap, settings := UpdateFromRoster(
+			Map{"ch/sales": {"jane", "karl"}, "ch/ux": {"davy"}},
+			Settings{"ch/sales": {Handle: "@org/sales", ExtraMembers: []string{"jane", "karl"}, Maintainers: []string{"karl"}}},
+			Map{"ch/sales": {"jane", "gregory"}},
+			Settings{"ch/sales": {}},
+		)
+
+		assert.Equal(Map{"ch/sales": {"jane", "gregory"}, "ch/ux": {"davy"}}, teamMap)
+		assert.Equal(Settings{"ch/sales": {Handle: "@org/sales", ExtraMembers: []string{"jane"}}}, settings)
+	})
+}
@@ -0,0 +1,46 @@
+package teams
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+func score(seed string, pattern string, member string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed + "\x00" + pattern + "\x00" + member))
+	return hash.Sum64()
+}
+
+// selectMembers returns count members of the team, chosen by the highest
+// hash of the seed, the pattern and the member (a.k.a. 'rendezvous
+// hashing'). For the same seed and pattern the selection is always the
+// same, and it only changes a little when a member joins or leaves the
+// team. Different patterns get different selections, which spreads the
+// load across the team. The selected members keep the order they had in
+// the team.
+func selectMembers(members []string, count int, seed string, pattern string) []string {
+	if count <= 0 || count >= len(members) {
+		return members
+	}
+	ranked := slices.Clone(members)
+	slices.SortStableFunc(ranked, func(a, b string) int {
+		return cmp.Compare(score(seed, pattern, b), score(seed, pattern, a))
+	})
+	selected := ranked[:count]
+
+	return slices.DeleteFunc(slices.Clone(members), func(member string) bool {
+		return !slices.Contains(selected, member)
+	})
+}
+
+// getSelectionPattern returns what to base the selection of members on for
+// a line: the rule pattern for rules, the section name for section headings
+func getSelectionPattern(line codeowners.Line) string {
+	if line.Type == "section-heading" {
+		return "[" + line.SectionName + "]"
+	}
+	return line.RulePattern
+}
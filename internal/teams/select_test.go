@@ -0,0 +1,52 @@
+package teams
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectMembers(t *testing.T) {
+	assert := assert.New(t)
+	members := []string{"user1", "user2", "user3", "user4", "user5", "user6"}
+
+	t.Run("returns all members when the count is 0 or not smaller than the team", func(t *testing.T) {
+		assert.Equal(members, selectMembers(members, 0, "", "src/"))
+		assert.Equal(members, selectMembers(members, 6, "", "src/"))
+		assert.Equal(members, selectMembers(members, 7, "", "src/"))
+	})
+
+	t.Run("returns count members, in team order", func(t *testing.T) {
+		selected := selectMembers(members, 2, "", "src/")
+
+		assert.Equal(2, len(selected))
+		assert.Less(slices.Index(members, selected[0]), slices.Index(members, selected[1]))
+	})
+
+	t.Run("is deterministic", func(t *testing.T) {
+		assert.Equal(selectMembers(members, 3, "seed", "src/"), selectMembers(members, 3, "seed", "src/"))
+	})
+
+	t.Run("spreads the load over patterns", func(t *testing.T) {
+		var selectedCount = map[string]int{}
+		for _, pattern := range []string{"a/", "b/", "c/", "d/", "e/", "f/", "g/", "h/", "i/", "j/", "k/", "l/"} {
+			for _, member := range selectMembers(members, 2, "", pattern) {
+				selectedCount[member]++
+			}
+		}
+		assert.Greater(len(selectedCount), 2)
+	})
+
+	t.Run("keeps the selection when an unselected member leaves", func(t *testing.T) {
+		selected := selectMembers(members, 3, "seed", "src/")
+		unselected := slices.DeleteFunc(slices.Clone(members), func(member string) bool {
+			return slices.Contains(selected, member)
+		})
+		remainingMembers := slices.DeleteFunc(slices.Clone(members), func(member string) bool {
+			return member == unselected[0]
+		})
+
+		assert.Equal(selected, selectMembers(remainingMembers, 3, "seed", "src/"))
+	})
+}
@@ -0,0 +1,177 @@
+package terraform
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// Options influence the Terraform Format emits
+type Options struct {
+	// When set, each team gets access to this repository
+	// (github_team_repository) with the Permission
+	Repository string
+	// pull, triage, push (default), maintain or admin
+	Permission string
+	// To look up the GitHub usernames of members listed by e-mail address
+	Identities codeowners.Identities
+}
+
+var nonIdentifierCharactersPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// getResourceName turns a name into (the part of) a Terraform identifier:
+// lower case letters, digits and underscores
+func getResourceName(name string) string {
+	return strings.Trim(nonIdentifierCharactersPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// getResourceNames returns a stable, unique resource name for each of the
+// names. When names end up the same, the later ones (in alphabetical order)
+// get a number. Identifiers can't start with a digit, so those get the
+// prefix.
+func getResourceNames(names []string, prefix string) map[string]string {
+	resourceNames := map[string]string{}
+	taken := map[string]bool{}
+
+	for _, name := range slices.Sorted(slices.Values(names)) {
+		baseName := getResourceName(name)
+		if baseName == "" || (baseName[0] >= '0' && baseName[0] <= '9') {
+			baseName = prefix + baseName
+		}
+		resourceName := baseName
+		for suffix := 2; taken[resourceName]; suffix++ {
+			resourceName = baseName + "_" + strconv.Itoa(suffix)
+		}
+		taken[resourceName] = true
+		resourceNames[name] = resourceName
+	}
+	return resourceNames
+}
+
+// getGitHubTeamNames returns the names the real teams get: the name of the
+// virtual team without its prefix (e.g. 'sales' for 'ch/sales'), unless
+// that clashes with another team. Then it's the whole name with dashes.
+func getGitHubTeamNames(teamNames []string) map[string]string {
+	gitHubTeamNames := map[string]string{}
+	count := map[string]int{}
+
+	shortName := func(team string) string {
+		_, name, found := strings.Cut(team, "/")
+		if !found {
+			return team
+		}
+		return strings.ReplaceAll(name, "/", "-")
+	}
+	for _, team := range teamNames {
+		count[shortName(team)]++
+	}
+	for _, team := range teamNames {
+		if count[shortName(team)] > 1 {
+			gitHubTeamNames[team] = strings.ReplaceAll(team, "/", "-")
+		} else {
+			gitHubTeamNames[team] = shortName(team)
+		}
+	}
+	return gitHubTeamNames
+}
+
+// writeBlock writes a resource block with its attributes aligned the way
+// terraform fmt does
+func writeBlock(output *strings.Builder, resourceType string, resourceName string, attributes [][2]string) {
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute[0]))
+	}
+	fmt.Fprintf(output, "resource \"%s\" \"%s\" {\n", resourceType, resourceName)
+	for _, attribute := range attributes {
+		fmt.Fprintf(output, "  %-*s = %s\n", width, attribute[0], attribute[1])
+	}
+	output.WriteString("}\n\n")
+}
+
+// getUsername returns the GitHub username of a member, and false when it's
+// an e-mail address without a GitHub username
+func getUsername(member string, options Options) (string, bool) {
+	owner := codeowners.ParseOwner(member)
+	if owner.Type != "e-mail" {
+		return member, true
+	}
+	normalizedOwner, found := options.Identities.Normalize(owner, "github")
+	return strings.TrimPrefix(normalizedOwner.Name, "@"), found
+}
+
+// Format returns Terraform for the integrations/github provider that
+// creates a real GitHub team for each virtual team (github_team), with the
+// same members (github_team_membership) and optionally access to a
+// repository (github_team_repository). Maintainers of the virtual team are
+// maintainers of the real one. Resource names are derived from the team and
+// member names, so they're stable between runs. Teams that already have a
+// real team handle are skipped. A dash separates the team from the member in
+// the name of a membership: it can't occur in either, so memberships of
+// different teams can't end up with the same name.
+func Format(teamMap teams.Map, settings teams.Settings, options Options) string {
+	var output strings.Builder
+	permission := options.Permission
+	if permission == "" {
+		permission = "push"
+	}
+
+	var teamNames []string
+	for _, team := range slices.Sorted(maps.Keys(teamMap)) {
+		if settings[team].Handle != "" {
+			fmt.Fprintf(&output, "# '%s' is already the real team '%s'\n\n", team, settings[team].Handle)
+			continue
+		}
+		teamNames = append(teamNames, team)
+	}
+	resourceNames := getResourceNames(teamNames, "team_")
+	gitHubTeamNames := getGitHubTeamNames(teamNames)
+
+	for _, team := range teamNames {
+		resourceName := resourceNames[team]
+		teamID := "github_team." + resourceName + ".id"
+
+		writeBlock(&output, "github_team", resourceName, [][2]string{
+			{"name", strconv.Quote(gitHubTeamNames[team])},
+			{"description", strconv.Quote("Promoted from the virtual team '" + team + "' by vcodeowners")},
+			{"privacy", strconv.Quote("closed")},
+		})
+
+		var usernames []string
+		for _, member := range teamMap[team] {
+			username, found := getUsername(member, options)
+			if !found {
+				fmt.Fprintf(&output, "# %s (team '%s') has no GitHub username\n\n", member, team)
+				continue
+			}
+			usernames = append(usernames, username)
+		}
+		membershipNames := getResourceNames(usernames, "")
+		for _, username := range usernames {
+			role := "member"
+			if slices.Contains(settings[team].Maintainers, username) {
+				role = "maintainer"
+			}
+			writeBlock(&output, "github_team_membership", resourceName+"-"+membershipNames[username], [][2]string{
+				{"team_id", teamID},
+				{"username", strconv.Quote(username)},
+				{"role", strconv.Quote(role)},
+			})
+		}
+
+		if options.Repository != "" {
+			writeBlock(&output, "github_team_repository", resourceName, [][2]string{
+				{"team_id", teamID},
+				{"repository", strconv.Quote(options.Repository)},
+				{"permission", strconv.Quote(permission)},
+			})
+		}
+	}
+	return strings.TrimSuffix(output.String(), "\n")
+}
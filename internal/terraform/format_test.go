@@ -0,0 +1,107 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+func TestFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("teams, memberships and repository access", func(t *testing.T) {
+		formatted := Format(
+			teams.Map{
+				"ch/sales":    {"jane-doe", "karl@example.com", "piet@example.com"},
+				"ch/ux":       {"davy"},
+				"ch/platform": {"mary"},
+			},
+			teams.Settings{
+				"ch/sales":    {Maintainers: []string{"jane-doe"}},
+				"ch/platform": {Handle: "@cloud-heroes/platform"},
+			},
+			Options{
+				Repository: "shop",
+				Identities: codeowners.Identities{"karl@example.com": {"github": "karl-marx"}},
+			},
+		)
+
+		assert.Equal(`# 'ch/platform' is already the real team '@cloud-heroes/platform'
+
+resource "github_team" "ch_sales" {
+  name        = "sales"
+  description = "Promoted from the virtual team 'ch/sales' by vcodeowners"
+  privacy     = "closed"
+}
+
+# piet@example.com (team 'ch/sales') has no GitHub username
+
+resource "github_team_membership" "ch_sales-jane_doe" {
+  team_id  = github_team.ch_sales.id
+  username = "jane-doe"
+  role     = "maintainer"
+}
+
+resource "github_team_membership" "ch_sales-karl_marx" {
+  team_id  = github_team.ch_sales.id
+  username = "karl-marx"
+  role     = "member"
+}
+
+resource "github_team_repository" "ch_sales" {
+  team_id    = github_team.ch_sales.id
+  repository = "shop"
+  permission = "push"
+}
+
+resource "github_team" "ch_ux" {
+  name        = "ux"
+  description = "Promoted from the virtual team 'ch/ux' by vcodeowners"
+  privacy     = "closed"
+}
+
+resource "github_team_membership" "ch_ux-davy" {
+  team_id  = github_team.ch_ux.id
+  username = "davy"
+  role     = "member"
+}
+
+resource "github_team_repository" "ch_ux" {
+  team_id    = github_team.ch_ux.id
+  repository = "shop"
+  permission = "push"
+}
+`, formatted)
+	})
+
+	t.Run("no repository, no repository access", func(t *testing.T) {
+		assert.NotContains(Format(teams.Map{"ux": {"davy"}}, nil, Options{}), "github_team_repository")
+	})
+
+	t.Run("memberships of different teams don't get the same name", func(t *testing.T) {
+		formatted := Format(teams.Map{"a": {"b_c"}, "a_b": {"c"}}, nil, Options{})
+
+		assert.Contains(formatted, `resource "github_team_membership" "a-b_c" {`)
+		assert.Contains(formatted, `resource "github_team_membership" "a_b-c" {`)
+	})
+}
+
+func TestGetResourceNames(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(
+		map[string]string{"Ch.Sales": "ch_sales", "ch-sales": "ch_sales_2", "ch/sales": "ch_sales_3", "42": "team_42"},
+		getResourceNames([]string{"ch/sales", "ch-sales", "42", "Ch.Sales"}, "team_"),
+	)
+}
+
+func TestGetGitHubTeamNames(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(
+		map[string]string{"ch/sales": "ch-sales", "ex/sales": "ex-sales", "ch/ux": "ux", "refunds": "refunds", "ch/sales/emea": "sales-emea"},
+		getGitHubTeamNames([]string{"ch/sales", "ex/sales", "ch/ux", "refunds", "ch/sales/emea"}),
+	)
+}
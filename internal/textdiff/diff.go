@@ -0,0 +1,154 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Match says item From of the first list is the same as item To of the
+// second
+type Match struct {
+	From int
+	To   int
+}
+
+// Align returns the longest common subsequence of the two lists as pairs
+// of indexes, in order
+func Align[T comparable](from []T, to []T) []Match {
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var matches []Match
+	for i, j := 0, 0; i < len(from) && j < len(to); {
+		switch {
+		case from[i] == to[j]:
+			matches = append(matches, Match{From: i, To: j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// edit is a line in a diff: ' ' for a line in both, '-' for one that's only
+// in the first text and '+' for one that's only in the second
+type edit struct {
+	kind byte
+	line string
+}
+
+func getEdits(fromLines []string, toLines []string) []edit {
+	var edits []edit
+	i, j := 0, 0
+
+	for _, match := range append(Align(fromLines, toLines), Match{From: len(fromLines), To: len(toLines)}) {
+		for ; i < match.From; i++ {
+			edits = append(edits, edit{kind: '-', line: fromLines[i]})
+		}
+		for ; j < match.To; j++ {
+			edits = append(edits, edit{kind: '+', line: toLines[j]})
+		}
+		if match.From < len(fromLines) {
+			edits = append(edits, edit{kind: ' ', line: fromLines[match.From]})
+			i++
+			j++
+		}
+	}
+	return edits
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified returns the differences between the texts in unified diff format
+// with three lines of context, or an empty string when they're the same
+func Unified(fromName string, toName string, from string, to string) string {
+	const context = 3
+	edits := getEdits(splitLines(from), splitLines(to))
+
+	var output strings.Builder
+	fromLineNo, toLineNo := 1, 1
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			fromLineNo++
+			toLineNo++
+			start++
+			continue
+		}
+		// a hunk starts with up to 'context' unchanged lines, and ends when
+		// there are more than twice that many unchanged lines in a row
+		hunkStart := max(0, start-context)
+		end := start
+		for unchanged := 0; end < len(edits) && unchanged <= 2*context; end++ {
+			if edits[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		hunkEnd := end
+		for hunkEnd > start && edits[hunkEnd-1].kind == ' ' {
+			hunkEnd--
+		}
+		hunkEnd = min(len(edits), hunkEnd+context)
+
+		hunkFromLineNo := fromLineNo - (start - hunkStart)
+		hunkToLineNo := toLineNo - (start - hunkStart)
+		fromCount, toCount := 0, 0
+		var hunk strings.Builder
+		for _, edit := range edits[hunkStart:hunkEnd] {
+			hunk.WriteString(string(edit.kind) + edit.line + "\n")
+			if edit.kind != '+' {
+				fromCount++
+			}
+			if edit.kind != '-' {
+				toCount++
+			}
+		}
+		if output.Len() == 0 {
+			fmt.Fprintf(&output, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&output, "@@ -%s +%s @@\n", formatRange(hunkFromLineNo, fromCount), formatRange(hunkToLineNo, toCount))
+		output.WriteString(hunk.String())
+
+		for _, edit := range edits[start:hunkEnd] {
+			if edit.kind != '+' {
+				fromLineNo++
+			}
+			if edit.kind != '-' {
+				toLineNo++
+			}
+		}
+		start = hunkEnd
+	}
+	return output.String()
+}
+
+func formatRange(lineNo int, count int) string {
+	if count == 0 {
+		lineNo--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", lineNo)
+	}
+	return fmt.Sprintf("%d,%d", lineNo, count)
+}
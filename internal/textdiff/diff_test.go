@@ -0,0 +1,49 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlign(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(
+		[]Match{{From: 0, To: 0}, {From: 2, To: 1}, {From: 3, To: 3}},
+		Align([]string{"a", "b", "c", "d"}, []string{"a", "c", "x", "d"}),
+	)
+	assert.Nil(Align([]string{"a"}, []string{}))
+}
+
+func TestUnified(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("same texts, no diff", func(t *testing.T) {
+		assert.Equal("", Unified("a", "b", "one\ntwo\n", "one\ntwo\n"))
+	})
+
+	t.Run("one hunk with context", func(t *testing.T) {
+		assert.Equal(
+			"--- a/file\n+++ b/file\n"+
+				"@@ -2,7 +2,7 @@\n"+
+				" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+			Unified("a/file", "b/file", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n"),
+		)
+	})
+
+	t.Run("hunks far apart, additions and deletions", func(t *testing.T) {
+		assert.Equal(
+			"--- a\n+++ b\n"+
+				"@@ -1,3 +1,4 @@\n"+
+				"+0\n 1\n 2\n 3\n"+
+				"@@ -9,4 +10,3 @@\n"+
+				" 9\n 10\n 11\n-12\n",
+			Unified("a", "b", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"),
+		)
+	})
+
+	t.Run("from nothing", func(t *testing.T) {
+		assert.Equal("--- a\n+++ b\n@@ -0,0 +1,2 @@\n+1\n+2\n", Unified("a", "b", "", "1\n2\n"))
+	})
+}
@@ -5,8 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/sverweij/vcodeowners/internal/backstage"
+	"github.com/sverweij/vcodeowners/internal/checksum"
 	"github.com/sverweij/vcodeowners/internal/codeowners"
 	"github.com/sverweij/vcodeowners/internal/json"
 	"github.com/sverweij/vcodeowners/internal/labeler"
@@ -30,88 +36,411 @@ func validateValid(validate string) bool {
 	return validValidateOptions[validate]
 }
 
+// handleAnomalies returns the anomalies as a message or as an error,
+// depending on the validate option
+func handleAnomalies(anomalies codeowners.Anomalies, heading string, validate string) (string, error) {
+	if len(anomalies) == 0 || validate == "skip" {
+		return "", nil
+	}
+	if validate == "fail" {
+		return "", fmt.Errorf("%s", anomalies.Report(heading))
+	}
+	return anomalies.Report(heading), nil
+}
+
+func platformValid(platform string) bool {
+	var validPlatformOptions = map[string]bool{
+		"github": true,
+		"gitlab": true,
+	}
+	return validPlatformOptions[platform]
+}
+
+func normalizationValid(normalization string) bool {
+	var validNormalizationOptions = map[string]bool{
+		"none":      true,
+		"lowercase": true,
+		"canonical": true,
+	}
+	return validNormalizationOptions[normalization]
+}
+
+func orderingValid(ordering string) bool {
+	var validOrderingOptions = map[string]bool{
+		"alphabetical": true,
+		"source":       true,
+		"team":         true,
+	}
+	return validOrderingOptions[ordering]
+}
+
+// reportWarnings returns the warnings as a message, unless the validate
+// option says to skip them
+func reportWarnings(warnings codeowners.Anomalies, validate string) string {
+	if len(warnings) == 0 || validate == "skip" {
+		return ""
+	}
+	return warnings.Report("Warnings:")
+}
+
+// readFile returns the content of the file as a string
+func readFile(fileName string) (string, error) {
+	bytes, readFileError := os.ReadFile(fileName)
+	return string(bytes), readFileError
+}
+
+// readVirtualCodeOwners reads the VIRTUAL-CODEOWNERS.txt with the lines of
+// the files its #!include directives refer to, and where each line came from
+func readVirtualCodeOwners(fileName string) (string, codeowners.Origins, error) {
+	content, readFileError := readFile(fileName)
+	if readFileError != nil {
+		return "", nil, readFileError
+	}
+	return codeowners.ExpandIncludes(content, fileName, readFile)
+}
+
+// readIdentities reads the identities file, if there is one
+func readIdentities(fileName string) (codeowners.Identities, error) {
+	if fileName == "" {
+		return nil, nil
+	}
+	identitiesBytes, identitiesReadError := os.ReadFile(fileName)
+	if identitiesReadError != nil {
+		return nil, identitiesReadError
+	}
+	return codeowners.ParseIdentities(string(identitiesBytes))
+}
+
 type cliOptionsType struct {
-	version           *bool
-	virtualCodeOwners *string
-	teamMap           *string
-	codeOwners        *string
-	validate          *string
-	dryRun            *bool
-	emitLabeler       *bool
-	labelerLocation   *string
-	json              *bool
+	version            *bool
+	virtualCodeOwners  *string
+	teamMap            *[]string
+	gitHubTeams        *[]string
+	teamMergeStrategy  *string
+	codeOwners         *string
+	validate           *string
+	dryRun             *bool
+	emitLabeler        *bool
+	labelerLocation    *string
+	json               *bool
+	ordering           *string
+	largeTeamThreshold *int
+	seed               *string
+	asOf               *string
+	identities         *string
+	platform           *string
+	normalizeOwners    *string
+	knownOwners        *string
+	ldifGroups         *string
+	ldifTeamName       *string
+	ldifHandle         *string
+	backstageCatalog   *[]string
+	distributedOwners  *string
 }
 
 const EXIT_CODE_ERROR = 1
 
+// subcommands maps the names of subcommands to the functions that run
+// them with the rest of the command line arguments
+var subcommands = map[string]func(arguments []string, output io.Writer) (string, error){
+	"init":                 initCli,
+	"backport":             backportCli,
+	"import-roster":        importRosterCli,
+	"merge-driver":         mergeDriverCli,
+	"install-merge-driver": installMergeDriverCli,
+	"diff-teams":           diffTeamsCli,
+	"diff-ownership":       diffOwnershipCli,
+	"export-backstage":     exportBackstageCli,
+	"export-terraform":     exportTerraformCli,
+	"verify":               verifyCli,
+}
+
+// addOptions defines the options for generating CODEOWNERS on the flag set
+func addOptions(flags *flag.FlagSet) cliOptionsType {
+	virtualTeams := stringListFlag{values: []string{".github/virtual-teams.json"}}
+	flags.Var(&virtualTeams, "virtualTeams", "A JSON file listing teams and their members, or an LDIF export of a directory. Repeat it, or pass a directory or a glob to combine multiple files")
+
+	gitHubTeams := stringListFlag{}
+	flags.Var(&gitHubTeams, "gitHubTeams", "An export of the teams of a GitHub organization and their members. Its teams become virtual teams as they are. Repeat it, or pass a directory or a glob to combine multiple files")
+
+	backstageCatalog := stringListFlag{}
+	flags.Var(&backstageCatalog, "backstageCatalog", "A Backstage catalog file. Its groups become virtual teams, its components with a source location rules. Repeat it, or pass a directory (for all catalog-info.yaml files in it) or a glob")
+
+	cliOptions := cliOptionsType{
+		version:            flags.Bool("version", false, "output the version number"),
+		virtualCodeOwners:  flags.String("virtualCodeOwners", ".github/VIRTUAL-CODEOWNERS.txt", "A CODEOWNERS file with team names in them that are defined in a virtual teams file"),
+		teamMap:            &virtualTeams.values,
+		gitHubTeams:        &gitHubTeams.values,
+		backstageCatalog:   &backstageCatalog.values,
+		teamMergeStrategy:  flags.String("teamMergeStrategy", "error", "What to do with teams defined in more than one virtual teams file. error: exit, union: combine them, override: use the last one"),
+		codeOwners:         flags.String("codeOwners", ".github/CODEOWNERS", "The CODEOWNERS file to merge the virtual teams into"),
+		validate:           flags.String("validate", "fail", "fail: exit on syntax errors, warn: print syntax errors & continue, skip: ignore syntax errors"),
+		dryRun:             flags.Bool("dryRun", false, "Just validate inputs, don't generate outputs"),
+		emitLabeler:        flags.Bool("emitLabeler", false, "Whether or not to emit a labeler.yml to be used with actions/labeler"),
+		labelerLocation:    flags.String("labelerLocation", ".github/labeler.yml", "The location of the labeler.yml file"),
+		json:               flags.Bool("json", false, "Output JSON to stdout (in addition to writing CODEOWNERS)"),
+		largeTeamThreshold: flags.Int("largeTeamThreshold", 0, "Emit the fallback (or maintainers) of virtual teams with more members than this. 0: no threshold"),
+		identities:         flags.String("identities", "", "A JSON file mapping e-mail addresses to the handles of their owners per platform"),
+		platform:           flags.String("platform", "github", "The platform the CODEOWNERS file is for: github, gitlab"),
+		ldifGroups:         flags.String("ldifGroups", "^cn=([^,]+)", "Regular expression for the DNs of the groups in .ldif virtual teams files to use as virtual teams (case insensitive)"),
+		ldifTeamName:       flags.String("ldifTeamName", "$1", "The name of virtual teams from .ldif files, with $1, $2, ... replaced by the groups in --ldifGroups"),
+		ldifHandle:         flags.String("ldifHandle", "githubUsername", "The attribute of users in .ldif files that holds their handle"),
+		knownOwners:        flags.String("knownOwners", "", "A list (or a GitHub/GitLab API export in JSON) of the users and teams in the organization. Reports owners that aren't in it"),
+		normalizeOwners:    flags.String("normalizeOwners", "none", "none: treat owner names case sensitively, lowercase: emit them in lower case, canonical: emit them as spelled in the virtual teams file"),
+		asOf:               flags.String("asOf", "", "Only include team members that are active on this date (YYYY-MM-DD). Default: today"),
+		seed:               flags.String("seed", "", "Seed for selecting members of teams with a 'reviewersPerRule' setting"),
+		distributedOwners:  flags.String("distributedOwners", "", "Also read owners files with this name (e.g. VIRTUAL-CODEOWNERS.txt) in subdirectories. Their patterns are relative to their directory"),
+		ordering:           flags.String("ordering", "alphabetical", "alphabetical: sort owners by name, source: keep the order of VIRTUAL-CODEOWNERS.txt & virtual-teams.json, team: owners that aren't a team first, then the members of each team, sorted within the team"),
+	}
+	return cliOptions
+}
+
 func getOptions(stderr io.Writer) cliOptionsType {
 	flag.Usage = func() {
 		fmt.Fprint(stderr, "Usage: vcodeowners [options]\n\n")
 		fmt.Fprint(stderr, "Merges a VIRTUAL-CODEOWNERS.txt and a virtual-teams.json into CODEOWNERS\n\n")
+		fmt.Fprint(stderr, "Subcommands (run them with --help for their options):\n")
+		fmt.Fprint(stderr, "  init                  propose virtual teams for an existing CODEOWNERS\n")
+		fmt.Fprint(stderr, "  backport              turn edits made by hand to CODEOWNERS into edits of its sources\n")
+		fmt.Fprint(stderr, "  import-roster         build or update a virtual-teams.json from a CSV roster\n")
+		fmt.Fprint(stderr, "  diff-teams            show the membership changes between two sets of teams\n")
+		fmt.Fprint(stderr, "  diff-ownership        show who gains and loses ownership of what between two revisions\n")
+		fmt.Fprint(stderr, "  export-backstage      write the virtual teams as Backstage Group entities\n")
+		fmt.Fprint(stderr, "  export-terraform      write Terraform that turns the virtual teams into real GitHub teams\n")
+		fmt.Fprint(stderr, "  install-merge-driver  let git regenerate CODEOWNERS on merges instead of leaving conflicts\n")
+		fmt.Fprint(stderr, "  verify                check CODEOWNERS wasn't edited by hand and is up to date with its sources\n\n")
 		flag.PrintDefaults()
 	}
 
-	cliOptions := cliOptionsType{
-		version:           flag.Bool("version", false, "output the version number"),
-		virtualCodeOwners: flag.String("virtualCodeOwners", ".github/VIRTUAL-CODEOWNERS.txt", "A CODEOWNERS file with team names in them that are defined in a virtual teams file"),
-		teamMap:           flag.String("virtualTeams", ".github/virtual-teams.json", "A JSON file listing teams and their members"),
-		codeOwners:        flag.String("codeOwners", ".github/CODEOWNERS", "The CODEOWNERS file to merge the virtual teams into"),
-		validate:          flag.String("validate", "fail", "fail: exit on syntax errors, warn: print syntax errors & continue, skip: ignore syntax errors"),
-		dryRun:            flag.Bool("dryRun", false, "Just validate inputs, don't generate outputs"),
-		emitLabeler:       flag.Bool("emitLabeler", false, "Whether or not to emit a labeler.yml to be used with actions/labeler"),
-		labelerLocation:   flag.String("labelerLocation", ".github/labeler.yml", "The location of the labeler.yml file"),
-		json:              flag.Bool("json", false, "Output JSON to stdout (in addition to writing CODEOWNERS)"),
-	}
-
+	cliOptions := addOptions(flag.CommandLine)
 	flag.Parse()
 	return cliOptions
 }
 
-func cli(options cliOptionsType) (string, error) {
-	returnMessage := ""
+// generation is what generating CODEOWNERS from the sources results in
+type generation struct {
+	// the lines of VIRTUAL-CODEOWNERS.txt and what they expand to
+	virtualLines codeowners.CST
+	lines        codeowners.CST
+	teamMap      teams.Map
+	// the options the virtual teams were expanded with
+	applyOptions teams.Options
+	// the period in which the team memberships are the same as on the
+	// asOf date (see teams.UnchangedPeriod)
+	unchangedFrom  time.Time
+	unchangedUntil time.Time
+	message        string
+}
 
-	if *options.version {
-		return VERSION, nil
+// parseAsOf returns the date of the asOf option, or today when it's empty
+func parseAsOf(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return time.Now(), nil
 	}
+	date, parseError := time.Parse("2006-01-02", asOf)
+	if parseError != nil {
+		return time.Time{}, fmt.Errorf("invalid asOf option '%s'; use a date like YYYY-MM-DD", asOf)
+	}
+	return date, nil
+}
+
+// formatDate formats the date as YYYY-MM-DD, and a zero date as ""
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// generate reads the sources the options point to and expands the virtual
+// teams in them
+func generate(options cliOptionsType) (generation, error) {
+	returnMessage := ""
+
 	if !validateValid(*options.validate) {
-		return "",
+		return generation{},
 			fmt.Errorf("invalid validate option '%s'; valid options: fail, warn, skip", *options.validate)
 	}
+	if !mergeStrategyValid(*options.teamMergeStrategy) {
+		return generation{},
+			fmt.Errorf("invalid teamMergeStrategy option '%s'; valid options: error, union, override", *options.teamMergeStrategy)
+	}
+	if !platformValid(*options.platform) {
+		return generation{},
+			fmt.Errorf("invalid platform option '%s'; valid options: github, gitlab", *options.platform)
+	}
+	if !normalizationValid(*options.normalizeOwners) {
+		return generation{},
+			fmt.Errorf("invalid normalizeOwners option '%s'; valid options: none, lowercase, canonical", *options.normalizeOwners)
+	}
+	if !orderingValid(*options.ordering) {
+		return generation{},
+			fmt.Errorf("invalid ordering option '%s'; valid options: alphabetical, source, team", *options.ordering)
+	}
 
-	bytes, readFileError := os.ReadFile(*options.virtualCodeOwners)
+	asOf, asOfError := parseAsOf(*options.asOf)
+	if asOfError != nil {
+		return generation{}, asOfError
+	}
+
+	ldifOptions, ldifOptionsError := getLDIFOptions(*options.ldifGroups, *options.ldifTeamName, *options.ldifHandle)
+	if ldifOptionsError != nil {
+		return generation{}, ldifOptionsError
+	}
+
+	virtualCodeOwners, origins, readFileError := readVirtualCodeOwners(*options.virtualCodeOwners)
 
 	if readFileError != nil {
-		return "", readFileError
+		return generation{}, readFileError
 	}
 
-	codeOwnersLines, syntaxErrors := codeowners.Parse(string(bytes))
+	catalog, catalogError := readCatalog(*options.backstageCatalog)
+	if catalogError != nil {
+		return generation{}, catalogError
+	}
+	// only components need the repository, and looking it up runs git
+	repository := ""
+	if catalog.HasComponents() {
+		repository = getRepositoryName()
+	}
+	backstageRules, backstageOrigins, backstageWarnings := backstage.FormatRules(catalog, repository)
+	returnMessage = returnMessage + reportWarnings(backstageWarnings, *options.validate)
 
-	if len(syntaxErrors) > 0 && (*options.validate != "skip") {
-		if *options.validate == "fail" {
-			return "", fmt.Errorf("%s", syntaxErrors.String())
+	codeOwnersLines, syntaxErrors := origins.Locate(codeowners.Parse(virtualCodeOwners))
+	if backstageRules != "" {
+		// the rules from the Backstage catalog go after an empty line
+		if !strings.HasSuffix(virtualCodeOwners, "\n") {
+			codeOwnersLines = append(codeOwnersLines, codeowners.Line{Type: "empty"})
 		}
-		returnMessage = returnMessage + syntaxErrors.String()
+		backstageLines, backstageSyntaxErrors := backstageOrigins.Locate(codeowners.Parse(backstageRules))
+		codeOwnersLines = append(codeOwnersLines, backstageLines...)
+		syntaxErrors = append(syntaxErrors, backstageSyntaxErrors...)
+	}
+	distributedLines, distributedSectionLines, distributedSyntaxErrors, distributedError := readDistributedOwners(*options.distributedOwners, *options.virtualCodeOwners)
+	if distributedError != nil {
+		return generation{}, distributedError
 	}
+	linesOutsideSections, linesInSections := splitAtSections(codeOwnersLines)
+	codeOwnersLines = slices.Concat(linesOutsideSections, distributedLines, linesInSections, distributedSectionLines)
+	syntaxErrors = append(syntaxErrors, distributedSyntaxErrors...)
 
-	teamMap := teams.Map{}
+	syntaxErrorMessage, syntaxError := handleAnomalies(syntaxErrors, "Syntax errors found in the input:", *options.validate)
+	if syntaxError != nil {
+		return generation{}, syntaxError
+	}
+	returnMessage = returnMessage + syntaxErrorMessage
 
-	if *options.teamMap != "" {
-		teamMapBytes, teamMapReadError := os.ReadFile(*options.teamMap)
-		if teamMapReadError != nil {
-			return "", teamMapReadError
+	teamMap, teamSettings, teamSourceWarnings, teamMapError := readTeamMaps(*options.teamMap, teamSourceOptions{
+		mergeStrategy: *options.teamMergeStrategy,
+		ldif:          ldifOptions,
+		gitHubTeams:   *options.gitHubTeams,
+		extraSources:  backstage.TeamSources(catalog),
+	})
+	if teamMapError != nil {
+		return generation{}, teamMapError
+	}
+	returnMessage = returnMessage + reportWarnings(teamSourceWarnings, *options.validate)
+	identities, identitiesError := readIdentities(*options.identities)
+	if identitiesError != nil {
+		return generation{}, identitiesError
+	}
+
+	unchangedFrom, unchangedUntil := teams.UnchangedPeriod(teamSettings, asOf)
+	teamMap, teamSettings, membershipWarnings := teams.ActiveOn(teamMap, teamSettings, asOf)
+	returnMessage = returnMessage + reportWarnings(membershipWarnings, *options.validate)
+	applyOptions := teams.Options{
+		Ordering:           *options.ordering,
+		Settings:           teamSettings,
+		LargeTeamThreshold: *options.largeTeamThreshold,
+		Seed:               *options.seed,
+		Identities:         identities,
+		Platform:           *options.platform,
+		Normalization:      *options.normalizeOwners,
+	}
+	transformedCodeOwnersLines, applyWarnings, applyError := teams.ApplyWithOptions(codeOwnersLines, teamMap, applyOptions)
+	if applyError != nil {
+		return generation{}, applyError
+	}
+	returnMessage = returnMessage + reportWarnings(applyWarnings, *options.validate)
+
+	return generation{
+		virtualLines:   codeOwnersLines,
+		lines:          transformedCodeOwnersLines,
+		teamMap:        teamMap,
+		applyOptions:   applyOptions,
+		unchangedFrom:  unchangedFrom,
+		unchangedUntil: unchangedUntil,
+		message:        returnMessage,
+	}, nil
+}
+
+func cli(options cliOptionsType) (string, error) {
+	if *options.version {
+		return VERSION, nil
+	}
+	generated, generateError := generate(options)
+	if generateError != nil {
+		return "", generateError
+	}
+	returnMessage := generated.message
+	transformedCodeOwnersLines := generated.lines
+
+	// the members of the virtual teams are people, not groups
+	teamMembers := codeowners.NewKnownOwners(slices.Concat(slices.Collect(maps.Values(generated.teamMap))...))
+	approversMessage, approversError := handleAnomalies(
+		codeowners.CheckMinApprovers(transformedCodeOwnersLines, *options.platform, teamMembers),
+		"Sections that can never be approved:",
+		*options.validate,
+	)
+	if approversError != nil {
+		return "", approversError
+	}
+	returnMessage = returnMessage + approversMessage
+
+	handlesMessage, handlesError := handleAnomalies(
+		codeowners.CheckHandles(transformedCodeOwnersLines, *options.platform),
+		"Owners that aren't valid on "+*options.platform+":",
+		*options.validate,
+	)
+	if handlesError != nil {
+		return "", handlesError
+	}
+	returnMessage = returnMessage + handlesMessage
+
+	if *options.knownOwners != "" {
+		knownOwnersBytes, knownOwnersReadError := os.ReadFile(*options.knownOwners)
+		if knownOwnersReadError != nil {
+			return "", knownOwnersReadError
+		}
+		knownOwners, knownOwnersParseError := codeowners.ParseKnownOwners(string(knownOwnersBytes))
+		if knownOwnersParseError != nil {
+			return "", knownOwnersParseError
 		}
-		var teamMapParseError error
-		teamMap, teamMapParseError = teams.Parse(string(teamMapBytes))
-		if teamMapParseError != nil {
-			return "", teamMapParseError
+		knownOwnersMessage, knownOwnersError := handleAnomalies(
+			codeowners.CheckKnownOwners(transformedCodeOwnersLines, knownOwners),
+			"Owners that aren't among the known owners:",
+			*options.validate,
+		)
+		if knownOwnersError != nil {
+			return "", knownOwnersError
 		}
+		returnMessage = returnMessage + knownOwnersMessage
 	}
-	transformedCodeOwnersLines := teams.Apply(codeOwnersLines, teamMap)
 
 	formatted, formatError := transformedCodeOwnersLines.Format(string(codeOwnersHeaderComment))
 	if formatError != nil {
 		return "", formatError
 	}
+	sourcesSum, sumError := getSourcesSum(options)
+	if sumError != nil {
+		return "", sumError
+	}
+	stamp := checksum.Stamp{
+		Sources: sourcesSum,
+		From:    formatDate(generated.unchangedFrom),
+		Until:   formatDate(generated.unchangedUntil),
+	}
+	formatted = checksum.Add(formatted, stamp)
 
 	if !*options.dryRun {
 		writeError := os.WriteFile(*options.codeOwners, []byte(formatted), 0644)
@@ -120,11 +449,11 @@ func cli(options cliOptionsType) (string, error) {
 		}
 		returnMessage = returnMessage + fmt.Sprintf("\nWrote '%s'\n", *options.codeOwners)
 		if *options.emitLabeler {
-			labelerFormatted, labelerFormatError := labeler.FormatCST(codeOwnersLines, teamMap, string(labelerHeaderComment))
+			labelerFormatted, labelerFormatError := labeler.FormatCST(generated.virtualLines, generated.teamMap, string(labelerHeaderComment))
 			if labelerFormatError != nil {
 				return "", labelerFormatError
 			}
-			labelerWriteError := os.WriteFile(*options.labelerLocation, []byte(labelerFormatted), 0644)
+			labelerWriteError := os.WriteFile(*options.labelerLocation, []byte(checksum.Add(labelerFormatted, stamp)), 0644)
 			if labelerWriteError != nil {
 				return "", labelerWriteError
 			}
@@ -143,9 +472,25 @@ func cli(options cliOptionsType) (string, error) {
 	return returnMessage, nil
 }
 
+// subcommandName returns the first command line argument when it isn't an
+// option
+func subcommandName(arguments []string) string {
+	if len(arguments) < 2 || strings.HasPrefix(arguments[1], "-") {
+		return ""
+	}
+	return arguments[1]
+}
+
 func main() {
-	cliOptions := getOptions(flag.CommandLine.Output())
-	message, error := cli(cliOptions)
+	var message string
+	var error error
+
+	if subcommand, found := subcommands[subcommandName(os.Args)]; found {
+		message, error = subcommand(os.Args[2:], flag.CommandLine.Output())
+	} else {
+		cliOptions := getOptions(flag.CommandLine.Output())
+		message, error = cli(cliOptions)
+	}
 
 	if error != nil {
 		fmt.Fprintln(flag.CommandLine.Output(), error.Error())
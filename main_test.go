@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -14,24 +15,54 @@ func initCliOptions() cliOptionsType {
 
 	version := false
 	virtualCodeOwners := ".github/VIRTUAL-CODEOWNERS.txt"
-	teamMap := ".github/virtual-teams.json"
+	teamMap := []string{".github/virtual-teams.json"}
+	teamMergeStrategy := "error"
 	codeOwners := ".github/CODEOWNERS"
 	validate := "fail"
 	dryRun := false
 	emitLabeler := false
 	labelerLocation := ".github/labeler.yml"
 	json := false
+	ordering := "alphabetical"
+	largeTeamThreshold := 0
+	seed := ""
+	asOf := ""
+	identities := ""
+	platform := "github"
+	normalizeOwners := "none"
+	knownOwners := ""
+	ldifGroups := "^cn=([^,]+)"
+	ldifTeamName := "$1"
+	ldifHandle := "githubUsername"
+	gitHubTeams := []string{}
+	backstageCatalog := []string{}
+	distributedOwners := ""
 
 	return cliOptionsType{
-		version:           &version,
-		virtualCodeOwners: &virtualCodeOwners,
-		teamMap:           &teamMap,
-		codeOwners:        &codeOwners,
-		validate:          &validate,
-		dryRun:            &dryRun,
-		emitLabeler:       &emitLabeler,
-		labelerLocation:   &labelerLocation,
-		json:              &json,
+		version:            &version,
+		virtualCodeOwners:  &virtualCodeOwners,
+		teamMap:            &teamMap,
+		gitHubTeams:        &gitHubTeams,
+		teamMergeStrategy:  &teamMergeStrategy,
+		codeOwners:         &codeOwners,
+		validate:           &validate,
+		dryRun:             &dryRun,
+		emitLabeler:        &emitLabeler,
+		labelerLocation:    &labelerLocation,
+		json:               &json,
+		ordering:           &ordering,
+		largeTeamThreshold: &largeTeamThreshold,
+		seed:               &seed,
+		asOf:               &asOf,
+		identities:         &identities,
+		platform:           &platform,
+		normalizeOwners:    &normalizeOwners,
+		knownOwners:        &knownOwners,
+		ldifGroups:         &ldifGroups,
+		ldifTeamName:       &ldifTeamName,
+		ldifHandle:         &ldifHandle,
+		backstageCatalog:   &backstageCatalog,
+		distributedOwners:  &distributedOwners,
 	}
 }
 
@@ -57,6 +88,66 @@ func TestCli(t *testing.T) {
 		assert.Equal("invalid validate option 'invalid'; valid options: fail, warn, skip", error.Error())
 	})
 
+	t.Run("invalid teamMergeStrategy option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		teamMergeStrategyValue := "coinflip"
+		options.teamMergeStrategy = &teamMergeStrategyValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid teamMergeStrategy option 'coinflip'; valid options: error, union, override", error.Error())
+	})
+
+	t.Run("invalid platform option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		platformValue := "bitbucket"
+		options.platform = &platformValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid platform option 'bitbucket'; valid options: github, gitlab", error.Error())
+	})
+
+	t.Run("invalid normalizeOwners option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		normalizeOwnersValue := "uppercase"
+		options.normalizeOwners = &normalizeOwnersValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid normalizeOwners option 'uppercase'; valid options: none, lowercase, canonical", error.Error())
+	})
+
+	t.Run("invalid ordering option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		orderingValue := "random"
+		options.ordering = &orderingValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid ordering option 'random'; valid options: alphabetical, source, team", error.Error())
+	})
+
+	t.Run("invalid asOf option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		asOfValue := "yesterday"
+		options.asOf = &asOfValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid asOf option 'yesterday'; use a date like YYYY-MM-DD", error.Error())
+	})
+
+	t.Run("invalid ldifGroups option returns an error", func(t *testing.T) {
+		options := initCliOptions()
+		ldifGroupsValue := "^cn=(unclosed"
+		options.ldifGroups = &ldifGroupsValue
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal("invalid ldifGroups option '^cn=(unclosed'; error parsing regexp: missing closing ): `(?i)^cn=(unclosed`", error.Error())
+	})
+
 	t.Run("invalid virtualCodeOwners file returns an error", func(t *testing.T) {
 		options := initCliOptions()
 		nonExistentFile := "non_existent_file.txt"
@@ -90,7 +181,7 @@ func TestCli(t *testing.T) {
 
 		options := initCliOptions()
 		options.virtualCodeOwners = &vcoFileName
-		options.teamMap = &teamsFileName
+		options.teamMap = &[]string{teamsFileName}
 		options.codeOwners = &coFileName
 		options.emitLabeler = &doEmitLabeler
 		options.labelerLocation = &labelerFileName
@@ -124,7 +215,7 @@ func TestCli(t *testing.T) {
 
 		options := initCliOptions()
 		options.virtualCodeOwners = &vcoFileName
-		options.teamMap = &teamsFileName
+		options.teamMap = &[]string{teamsFileName}
 		options.codeOwners = &coFileName
 		options.emitLabeler = &doEmitLabeler
 		options.labelerLocation = &labelerFileName
@@ -138,4 +229,315 @@ func TestCli(t *testing.T) {
 		assert.NotNil(labelerFileOpenError)
 		assert.Equal("\nWrote 'delete_me_CODEOWNERS_should_not_be_created' (dry run)\n\n", foundMessage)
 	})
+
+	t.Run("sections that can never be approved return an error", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		coFileName := "delete_me_CODEOWNERS_should_not_be_created"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("[sales][3] @ch/sales\nlibs/sales/\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", "karl"]}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal(
+			"Sections that can never be approved:\n"+
+				"  Line    1, Section 'sales' requires 3 approvals, but there's only 2 distinct owner(s): \"[sales][3] @ch/sales\"\n"+
+				"  Line    2, Section 'sales' requires 3 approvals, but there's only 2 distinct owner(s): \"libs/sales/\"\n",
+			error.Error(),
+		)
+		_, coFileOpenError := os.Open(coFileName)
+		assert.NotNil(coFileOpenError)
+	})
+
+	t.Run("teams above the large team threshold emit a warning", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		coFileName := "delete_me_CODEOWNERS"
+		largeTeamThreshold := 1
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": {"members": ["jane", "karl"], "fallback": "@org/sales"}}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		options.largeTeamThreshold = &largeTeamThreshold
+		foundMessage, error := cli(options)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Warnings:\n"+
+				"  Line    1, Team 'ch/sales' has 2 members, which is more than 1; using its fallback '@org/sales' instead: \"libs/sales/ @ch/sales\"\n"+
+				"\nWrote 'delete_me_CODEOWNERS'\n",
+			foundMessage,
+		)
+		codeOwners, _ := os.ReadFile(coFileName)
+		assert.Contains(string(codeOwners), "libs/sales/ @org/sales\n")
+	})
+
+	t.Run("only members active on --asOf end up in CODEOWNERS", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		coFileName := "delete_me_CODEOWNERS"
+		asOf := "2026-07-01"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", {"name": "karl", "until": "2026-06-30"}]}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		options.asOf = &asOf
+		foundMessage, error := cli(options)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Warnings:\n"+
+				"  Membership of team 'ch/sales' ended on 2026-06-30 (in 'delete_me_virtual-teams.json'): \"karl\"\n"+
+				"\nWrote 'delete_me_CODEOWNERS'\n",
+			foundMessage,
+		)
+		codeOwners, _ := os.ReadFile(coFileName)
+		assert.Contains(string(codeOwners), "libs/sales/ @jane\n")
+	})
+
+	t.Run("excluding someone who isn't an owner of the line returns an error", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		coFileName := "delete_me_CODEOWNERS_should_not_be_created"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/refund/ @ch/sales -@karl-marx-ch\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane"]}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal(
+			"line 1: can't exclude '@karl-marx-ch' as it isn't among the owners of the line: \"libs/refund/ @ch/sales -@karl-marx-ch\"",
+			error.Error(),
+		)
+	})
+
+	t.Run("e-mail addresses get replaced by handles from the identities file", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		identitiesFileName := "delete_me_identities.json"
+		coFileName := "delete_me_CODEOWNERS"
+		platform := "gitlab"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+			os.Remove(identitiesFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/baarden/ @team-beard\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"team-beard": ["jan@example.com", "pier@example.com"]}`), 0644)
+		os.WriteFile(identitiesFileName, []byte(`{"jan@example.com": {"github": "jan-gh", "gitlab": "jan-gl"}}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		options.identities = &identitiesFileName
+		options.platform = &platform
+		foundMessage, error := cli(options)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Warnings:\n"+
+				"  Line    1, No gitlab handle for e-mail address 'pier@example.com': \"libs/baarden/ @team-beard\"\n"+
+				"\nWrote 'delete_me_CODEOWNERS'\n",
+			foundMessage,
+		)
+		codeOwners, _ := os.ReadFile(coFileName)
+		assert.Contains(string(codeOwners), "libs/baarden/ @jan-gl pier@example.com\n")
+	})
+
+	t.Run("owners that aren't valid on the platform return an error", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		coFileName := "delete_me_CODEOWNERS_should_not_be_created"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane.doe"]}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal(
+			"Owners that aren't valid on github:\n"+
+				"  Line    1, Invalid github handle '@jane.doe' (user and organization names on GitHub only contain alphanumerics and single hyphens): \"libs/sales/ @ch/sales\"\n",
+			error.Error(),
+		)
+	})
+
+	t.Run("owners that aren't among the known owners return an error", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		knownOwnersFileName := "delete_me_known-owners.txt"
+		coFileName := "delete_me_CODEOWNERS_should_not_be_created"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+			os.Remove(knownOwnersFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane-doe", "karl"]}`), 0644)
+		os.WriteFile(knownOwnersFileName, []byte("jane-doe\n"), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		options.knownOwners = &knownOwnersFileName
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Equal(
+			"Owners that aren't among the known owners:\n"+
+				"  Line    1, Unknown owner '@karl': \"libs/sales/ @ch/sales\"\n",
+			error.Error(),
+		)
+	})
+
+	t.Run("groups and components from the Backstage catalog become teams and rules", func(t *testing.T) {
+		vcoFileName := "delete_me_VIRTUAL_CODEOWNERS.txt"
+		teamsFileName := "delete_me_virtual-teams.json"
+		catalogFileName := "delete_me_catalog-info.yaml"
+		coFileName := "delete_me_CODEOWNERS"
+		defer func() {
+			os.Remove(vcoFileName)
+			os.Remove(coFileName)
+			os.Remove(teamsFileName)
+			os.Remove(catalogFileName)
+		}()
+
+		os.WriteFile(vcoFileName, []byte("* @ch/ux"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/ux": ["davy"]}`), 0644)
+		os.WriteFile(catalogFileName, []byte(
+			"apiVersion: backstage.io/v1alpha1\n"+
+				"kind: Group\n"+
+				"metadata:\n  name: sales\n  namespace: ch\n"+
+				"spec:\n  type: team\n  children: []\n  members: [jane, karl]\n"+
+				"---\n"+
+				"apiVersion: backstage.io/v1alpha1\n"+
+				"kind: Component\n"+
+				"metadata:\n  name: sales\n  annotations:\n    backstage.io/source-location: url:https://github.com/ch/shop/tree/main/libs/sales/\n"+
+				"spec:\n  owner: group:ch/sales\n",
+		), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.backstageCatalog = &[]string{catalogFileName}
+		options.codeOwners = &coFileName
+		_, error := cli(options)
+
+		assert.Nil(error)
+		codeOwners, _ := os.ReadFile(coFileName)
+		assert.Contains(string(codeOwners), "* @davy\n\n# from the Backstage catalog\n/libs/sales/ @jane @karl\n")
+	})
+
+	t.Run("problems in rules from the Backstage catalog are reported with the catalog file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		vcoFileName := "VIRTUAL-CODEOWNERS.txt"
+		teamsFileName := "virtual-teams.json"
+		catalogFileName := "catalog-info.yaml"
+		knownOwnersFileName := "known-owners.txt"
+		coFileName := "CODEOWNERS"
+		os.WriteFile(vcoFileName, []byte("* @ch/ux\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/ux": ["davy"], "ch/sales": ["jane", "karl"]}`), 0644)
+		os.WriteFile(knownOwnersFileName, []byte("davy\njane\n"), 0644)
+		os.WriteFile(catalogFileName, []byte(
+			"# sales\n"+
+				"apiVersion: backstage.io/v1alpha1\n"+
+				"kind: Component\n"+
+				"metadata:\n  name: sales\n  annotations:\n    backstage.io/source-location: dir:libs/sales\n"+
+				"spec:\n  owner: group:ch/sales\n",
+		), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.backstageCatalog = &[]string{catalogFileName}
+		options.knownOwners = &knownOwnersFileName
+		options.codeOwners = &coFileName
+		_, error := cli(options)
+
+		assert.NotNil(error)
+		assert.Contains(error.Error(), "catalog-info.yaml:2, Unknown owner '@karl'")
+	})
+
+	t.Run("includes fragments with #!include and reports where problems are", func(t *testing.T) {
+		directory := t.TempDir()
+		vcoFileName := filepath.Join(directory, "VIRTUAL-CODEOWNERS.txt")
+		teamsFileName := filepath.Join(directory, "virtual-teams.json")
+		coFileName := filepath.Join(directory, "CODEOWNERS")
+		validate := "warn"
+		os.Mkdir(filepath.Join(directory, "domains"), 0755)
+		os.WriteFile(vcoFileName, []byte("* @ch/ux\n#!include domains/sales.txt\ndocs/ @ch/ux\n"), 0644)
+		os.WriteFile(filepath.Join(directory, "domains", "sales.txt"), []byte("libs/sales/ @ch/sales\nlibs/oops/ davy\n"), 0644)
+		os.WriteFile(teamsFileName, []byte(`{"ch/ux": ["davy"], "ch/sales": ["jane", "karl"]}`), 0644)
+
+		options := initCliOptions()
+		options.virtualCodeOwners = &vcoFileName
+		options.teamMap = &[]string{teamsFileName}
+		options.codeOwners = &coFileName
+		options.validate = &validate
+		foundMessage, error := cli(options)
+
+		assert.Nil(error)
+		assert.Equal(
+			"Syntax errors found in the input:\n"+
+				"  "+filepath.ToSlash(filepath.Join(directory, "domains", "sales.txt"))+":2, Invalid user 'davy': \"libs/oops/ davy\"\n"+
+				"\nWrote '"+coFileName+"'\n",
+			foundMessage,
+		)
+		codeOwners, _ := os.ReadFile(coFileName)
+		assert.Contains(string(codeOwners), "* @davy\nlibs/sales/ @jane @karl\nlibs/oops/ davy\ndocs/ @davy\n")
+	})
 }
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// getBlob returns the hash of the version of a file in a revision, or ""
+// when it isn't in there
+func getBlob(revision string, fileName string) string {
+	blob, revParseError := runGit("rev-parse", "-q", "--verify", revision+":"+filepath.ToSlash(fileName))
+	if revParseError != nil {
+		return ""
+	}
+	return strings.TrimSpace(blob)
+}
+
+// getMergeRevisions returns the revisions of the base, our and their version
+// of the sources. While git runs a merge driver there's no MERGE_HEAD or
+// CHERRY_PICK_HEAD yet, and the index doesn't have the stages of the file.
+// So for a merge it takes their commit from the GITHEAD_<commit> variable
+// git sets, and otherwise it looks up the commit in which the file went
+// from the base to the other version (a cherry-pick or a rebase) or the
+// other way around (a revert).
+func getMergeRevisions(base string, other string, fileName string) (string, string, string, error) {
+	for _, variable := range slices.Sorted(slices.Values(os.Environ())) {
+		if name, _, found := strings.Cut(variable, "="); found && strings.HasPrefix(name, "GITHEAD_") {
+			theirs := strings.TrimPrefix(name, "GITHEAD_")
+			mergeBase, mergeBaseError := runGit("merge-base", "HEAD", theirs)
+			return strings.TrimSpace(mergeBase), "HEAD", theirs, mergeBaseError
+		}
+	}
+
+	var blobs []string
+	for _, version := range []string{base, other} {
+		blob, hashError := runGit("hash-object", "--no-filters", version)
+		if hashError != nil {
+			return "", "", "", hashError
+		}
+		blobs = append(blobs, strings.TrimSpace(blob))
+	}
+	baseBlob, otherBlob := blobs[0], blobs[1]
+	commits, logError := runGit("log", "--all", "--format=%H", "--find-object="+otherBlob, "--", filepath.ToSlash(fileName))
+	if logError != nil {
+		return "", "", "", logError
+	}
+	for _, commit := range strings.Fields(commits) {
+		blob, parentBlob := getBlob(commit, fileName), getBlob(commit+"^", fileName)
+		switch {
+		case blob == otherBlob && parentBlob == baseBlob:
+			return commit + "^", "HEAD", commit, nil
+		case blob == baseBlob && parentBlob == otherBlob:
+			return commit, "HEAD", commit + "^", nil
+		}
+	}
+	return "", "", "", fmt.Errorf("couldn't find the merge, cherry-pick, revert or rebase '%s' is merged for", fileName)
+}
+
+// mergeSource returns the three way merge of the base, our and their version
+// of a source file, or an error when they conflict
+func mergeSource(fileName string, base string, ours string, theirs string, directory string) (string, error) {
+	switch {
+	case ours == theirs || base == theirs:
+		return ours, nil
+	case base == ours:
+		return theirs, nil
+	}
+	var versionFileNames []string
+	for i, version := range []string{ours, base, theirs} {
+		versionFileName := filepath.Join(directory, fmt.Sprintf("merge-%d", i))
+		if writeError := os.WriteFile(versionFileName, []byte(version), 0644); writeError != nil {
+			return "", writeError
+		}
+		versionFileNames = append(versionFileNames, versionFileName)
+	}
+	merged, mergeError := runGit(append([]string{"merge-file", "-p"}, versionFileNames...)...)
+	if mergeError != nil {
+		return "", fmt.Errorf("'%s' has conflicts", fileName)
+	}
+	return merged, nil
+}
+
+// mergeSources returns the options pointed to the merged versions of the
+// source files, which it writes to the directory. The base and other
+// version of the file the merge driver merges tell which revisions to merge.
+func mergeSources(options cliOptionsType, base string, other string, fileName string, directory string) (cliOptionsType, error) {
+	baseRevision, ourRevision, theirRevision, revisionsError := getMergeRevisions(base, other, fileName)
+	if revisionsError != nil {
+		return options, revisionsError
+	}
+	return withLocations(options, func(location string) (string, error) {
+		if locationError := checkSingleFile(location); locationError != nil {
+			return "", locationError
+		}
+		merged, mergeError := mergeSource(
+			location,
+			showRevision(baseRevision, location),
+			showRevision(ourRevision, location),
+			showRevision(theirRevision, location),
+			directory,
+		)
+		if mergeError != nil {
+			return "", mergeError
+		}
+		return writeSource(directory, location, merged)
+	})
+}
+
+// regenerate writes the CODEOWNERS (or labeler.yml) generated from the
+// merged sources to the current version of the file
+func regenerate(options cliOptionsType, base string, current string, other string, fileName string) error {
+	directory, tempError := os.MkdirTemp("", "vcodeowners-merge-")
+	if tempError != nil {
+		return tempError
+	}
+	defer os.RemoveAll(directory)
+
+	options, mergeError := mergeSources(options, base, other, fileName, directory)
+	if mergeError != nil {
+		return mergeError
+	}
+	dryRun := false
+	json := false
+	emitLabeler := filepath.Clean(fileName) == filepath.Clean(*options.labelerLocation)
+	codeOwners := current
+	labelerLocation := filepath.Join(directory, "labeler.yml")
+	if emitLabeler {
+		codeOwners = filepath.Join(directory, "CODEOWNERS")
+		labelerLocation = current
+	}
+	options.dryRun = &dryRun
+	options.json = &json
+	options.emitLabeler = &emitLabeler
+	options.codeOwners = &codeOwners
+	options.labelerLocation = &labelerLocation
+	_, generateError := cli(options)
+	return generateError
+}
+
+// mergeDriverCli is a git merge driver for CODEOWNERS and labeler.yml: it
+// regenerates them from the merged VIRTUAL-CODEOWNERS.txt and virtual
+// teams. When it can't (e.g. because the sources conflict) it leaves the
+// usual conflict markers.
+func mergeDriverCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("merge-driver", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners merge-driver [options] <base> <current> <other> <path>\n\n")
+		fmt.Fprint(output, "A git merge driver that regenerates CODEOWNERS or labeler.yml from the merged sources. Register it with 'vcodeowners install-merge-driver'\n\n")
+		flags.PrintDefaults()
+	}
+	options := addOptions(flags)
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+	if flags.NArg() != 4 {
+		flags.Usage()
+		return "", fmt.Errorf("merge-driver needs the base, current and other version and the path of the file")
+	}
+	base, current, other, fileName := flags.Arg(0), flags.Arg(1), flags.Arg(2), flags.Arg(3)
+
+	if regenerateError := regenerate(options, base, current, other, fileName); regenerateError != nil {
+		// git merge-file exits with the number of conflicts, so an error is
+		// expected here
+		runGit("merge-file", "-L", "current", "-L", "base", "-L", "other", current, base, other)
+		return "", fmt.Errorf("couldn't regenerate '%s': %w; resolve the conflicts by hand", fileName, regenerateError)
+	}
+	return fmt.Sprintf("Regenerated '%s' from the merged sources", fileName), nil
+}
+
+// shellQuote quotes the argument for use in a shell command, unless it
+// only has characters that don't need it
+func shellQuote(argument string) string {
+	if argument != "" && !strings.ContainsFunc(argument, func(character rune) bool {
+		return !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@%+,", character)
+	}) {
+		return argument
+	}
+	return "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+}
+
+// addAttributes adds a line that tells git to use the merge driver for each
+// of the files to the .gitattributes, unless it's already there
+func addAttributes(attributesFileName string, fileNames []string) ([]string, error) {
+	attributes, readError := os.ReadFile(attributesFileName)
+	if readError != nil && !errors.Is(readError, os.ErrNotExist) {
+		return nil, readError
+	}
+	content := string(attributes)
+	lines := strings.Split(content, "\n")
+	var added []string
+	for _, fileName := range fileNames {
+		line := filepath.ToSlash(fileName) + " merge=vcodeowners"
+		if slices.Contains(lines, line) {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content = content + "\n"
+		}
+		content = content + line + "\n"
+		added = append(added, line)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, os.WriteFile(attributesFileName, []byte(content), 0644)
+}
+
+// installMergeDriverCli registers the merge driver in .git/config and
+// tells git to use it for CODEOWNERS and labeler.yml in .gitattributes
+func installMergeDriverCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("install-merge-driver", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners install-merge-driver [options] [-- options for generating]\n\n")
+		fmt.Fprint(output, "Registers 'vcodeowners merge-driver' in .git/config and uses it for CODEOWNERS and labeler.yml in .gitattributes.\n")
+		fmt.Fprint(output, "Pass the options you generate CODEOWNERS with after a '--'\n\n")
+		flags.PrintDefaults()
+	}
+	command := flags.String("command", "vcodeowners", "The command git runs vcodeowners with")
+	codeOwners := flags.String("codeOwners", ".github/CODEOWNERS", "The generated CODEOWNERS file")
+	labelerLocation := flags.String("labelerLocation", ".github/labeler.yml", "The generated labeler.yml, if any")
+	emitLabeler := flags.Bool("emitLabeler", false, "Also use the merge driver for the labeler.yml")
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+
+	driverArguments := []string{*command, "merge-driver", "--codeOwners", *codeOwners, "--labelerLocation", *labelerLocation}
+	driverArguments = append(driverArguments, flags.Args()...)
+	var quotedArguments []string
+	for _, argument := range driverArguments {
+		quotedArguments = append(quotedArguments, shellQuote(argument))
+	}
+	// the command can have arguments of its own, e.g. 'go run ...'
+	quotedArguments[0] = *command
+	driver := strings.Join(quotedArguments, " ") + " %O %A %B %P"
+
+	if _, configError := runGit("config", "merge.vcodeowners.name", "regenerate with vcodeowners"); configError != nil {
+		return "", configError
+	}
+	if _, configError := runGit("config", "merge.vcodeowners.driver", driver); configError != nil {
+		return "", configError
+	}
+	returnMessage := fmt.Sprintf("Registered the merge driver in .git/config:\n  %s\n", driver)
+
+	fileNames := []string{*codeOwners}
+	if *emitLabeler {
+		fileNames = append(fileNames, *labelerLocation)
+	}
+	added, attributesError := addAttributes(".gitattributes", fileNames)
+	if attributesError != nil {
+		return "", attributesError
+	}
+	if len(added) > 0 {
+		returnMessage = returnMessage + "Added to .gitattributes:\n  " + strings.Join(added, "\n  ") + "\n"
+	}
+	return returnMessage, nil
+}
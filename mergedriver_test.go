@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func git(t *testing.T, arguments ...string) string {
+	t.Helper()
+	output, gitError := exec.Command("git", arguments...).CombinedOutput()
+	if gitError != nil && arguments[0] != "merge" {
+		t.Fatalf("git %v: %s", arguments, output)
+	}
+	return string(output)
+}
+
+func commitGenerated(t *testing.T, virtualCodeOwners string, virtualTeams string, message string) {
+	t.Helper()
+	os.WriteFile(".github/VIRTUAL-CODEOWNERS.txt", []byte(virtualCodeOwners), 0644)
+	os.WriteFile(".github/virtual-teams.json", []byte(virtualTeams), 0644)
+	if _, error := cli(initCliOptions()); error != nil {
+		t.Fatal(error)
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", message)
+}
+
+// buildCommand builds vcodeowners, for git to run as the merge driver
+func buildCommand(t *testing.T) string {
+	t.Helper()
+	command := filepath.Join(t.TempDir(), "vcodeowners")
+	if output, buildError := exec.Command("go", "build", "-o", command, ".").CombinedOutput(); buildError != nil {
+		t.Fatalf("go build: %s", output)
+	}
+	return command
+}
+
+// setUpBranches makes a repository with the merge driver registered, in
+// which the 'main' and the 'other' branch both changed the sources since the
+// base commit - and with that the CODEOWNERS generated from them.
+func setUpBranches(t *testing.T, command string, mainTeams string, otherTeams string) {
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	git(t, "config", "user.name", "jane")
+	git(t, "config", "user.email", "jane@example.com")
+	if _, installError := installMergeDriverCli([]string{"--command", command}, io.Discard); installError != nil {
+		t.Fatal(installError)
+	}
+	os.Mkdir(".github", 0755)
+	commitGenerated(t, "libs/sales/ @ch/sales\n", "{\"ch/sales\": [\"jane\"]}\n", "base")
+	git(t, "checkout", "-q", "-b", "other")
+	commitGenerated(t, "libs/sales/ @ch/sales\n", otherTeams, "other")
+	git(t, "checkout", "-q", "main")
+	commitGenerated(t, "libs/sales/ @ch/sales\ndocs/ @davy\n", mainTeams, "main")
+}
+
+func TestMergeDriverCli(t *testing.T) {
+	command := buildCommand(t)
+
+	for _, operation := range [][]string{
+		{"merge", "-q", "--no-edit", "other"},
+		{"cherry-pick", "other"},
+	} {
+		t.Run(operation[0]+" regenerates CODEOWNERS from the merged sources", func(t *testing.T) {
+			assert := assert.New(t)
+			setUpBranches(t, command, "{\"ch/sales\": [\"jane\"]}\n", "{\"ch/sales\": [\"jane\", \"karl\"]}\n")
+
+			git(t, operation...)
+
+			codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+			assert.Contains(string(codeOwners), "libs/sales/ @jane @karl\ndocs/ @davy\n")
+			_, verifyError := verifyCli([]string{}, io.Discard)
+			assert.Nil(verifyError)
+		})
+	}
+
+	t.Run("rebase regenerates CODEOWNERS from the merged sources", func(t *testing.T) {
+		assert := assert.New(t)
+		setUpBranches(t, command, "{\"ch/sales\": [\"jane\"]}\n", "{\"ch/sales\": [\"jane\", \"karl\"]}\n")
+		git(t, "checkout", "-q", "other")
+
+		git(t, "rebase", "-q", "main")
+
+		codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+		assert.Contains(string(codeOwners), "libs/sales/ @jane @karl\ndocs/ @davy\n")
+		_, verifyError := verifyCli([]string{}, io.Discard)
+		assert.Nil(verifyError)
+	})
+
+	t.Run("revert regenerates CODEOWNERS from the merged sources", func(t *testing.T) {
+		assert := assert.New(t)
+		setUpBranches(t, command, "{\"ch/sales\": [\"jane\"]}\n", "{\"ch/sales\": [\"jane\", \"karl\"]}\n")
+		git(t, "merge", "-q", "--no-edit", "other")
+
+		git(t, "revert", "--no-edit", "HEAD^1")
+
+		codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+		assert.Contains(string(codeOwners), "libs/sales/ @jane @karl\n")
+		assert.NotContains(string(codeOwners), "docs/")
+		_, verifyError := verifyCli([]string{}, io.Discard)
+		assert.Nil(verifyError)
+	})
+
+	t.Run("error: leaves conflict markers when the sources conflict", func(t *testing.T) {
+		assert := assert.New(t)
+		setUpBranches(t, command, "{\"ch/sales\": [\"jane\", \"davy\"]}\n", "{\"ch/sales\": [\"jane\", \"karl\"]}\n")
+
+		output := git(t, "merge", "-q", "--no-edit", "other")
+
+		assert.Contains(output, "couldn't regenerate '.github/CODEOWNERS': '.github/virtual-teams.json' has conflicts; resolve the conflicts by hand")
+		codeOwners, _ := os.ReadFile(".github/CODEOWNERS")
+		assert.Contains(string(codeOwners), "<<<<<<< current\n")
+	})
+
+	t.Run("error: needs four arguments", func(t *testing.T) {
+		_, error := mergeDriverCli([]string{"base", "current"}, io.Discard)
+
+		assert.NotNil(t, error)
+	})
+}
+
+func TestInstallMergeDriverCli(t *testing.T) {
+	assert := assert.New(t)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	os.WriteFile(".gitattributes", []byte("*.png binary"), 0644)
+
+	message, error := installMergeDriverCli([]string{"--emitLabeler", "--", "--ordering", "source", "--virtualTeams", "teams/my teams.json"}, io.Discard)
+
+	assert.Nil(error)
+	driver := "vcodeowners merge-driver --codeOwners .github/CODEOWNERS --labelerLocation .github/labeler.yml --ordering source --virtualTeams 'teams/my teams.json' %O %A %B %P"
+	assert.Equal(
+		"Registered the merge driver in .git/config:\n  "+driver+"\n"+
+			"Added to .gitattributes:\n  .github/CODEOWNERS merge=vcodeowners\n  .github/labeler.yml merge=vcodeowners\n",
+		message,
+	)
+	assert.Equal(driver+"\n", git(t, "config", "merge.vcodeowners.driver"))
+
+	_, error = installMergeDriverCli([]string{}, io.Discard)
+	assert.Nil(error)
+	attributes, _ := os.ReadFile(".gitattributes")
+	assert.Equal("*.png binary\n.github/CODEOWNERS merge=vcodeowners\n.github/labeler.yml merge=vcodeowners\n", string(attributes))
+}
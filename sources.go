@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+)
+
+// runGit runs git with the arguments and returns what it wrote to stdout
+func runGit(arguments ...string) (string, error) {
+	output, gitError := exec.Command("git", arguments...).Output()
+	var exitError *exec.ExitError
+	if errors.As(gitError, &exitError) && len(exitError.Stderr) > 0 {
+		return string(output), fmt.Errorf("git %s: %s", arguments[0], strings.TrimSpace(string(exitError.Stderr)))
+	}
+	return string(output), gitError
+}
+
+// showRevision returns the content of the file in the revision, or an empty
+// string when it isn't in there
+func showRevision(revision string, fileName string) string {
+	content, showError := runGit("show", revision+":"+filepath.ToSlash(fileName))
+	if showError != nil {
+		return ""
+	}
+	return content
+}
+
+// checkSingleFile returns an error when the location of a source is a
+// directory or a glob, which can't be read from a git revision as it is
+func checkSingleFile(location string) error {
+	if fileInfo, statError := os.Stat(location); (statError == nil && fileInfo.IsDir()) || strings.ContainsAny(location, "*?[") {
+		return fmt.Errorf("can't read '%s' from git; only files, not directories or globs", location)
+	}
+	return nil
+}
+
+// writeSource writes the content of a source to the same relative location
+// in the directory and returns where it wrote it
+func writeSource(directory string, location string, content string) (string, error) {
+	fileName := filepath.Join(directory, "sources", location)
+	if mkdirError := os.MkdirAll(filepath.Dir(fileName), 0755); mkdirError != nil {
+		return "", mkdirError
+	}
+	return fileName, os.WriteFile(fileName, []byte(content), 0644)
+}
+
+// relocateAll returns what relocate returns for each of the locations,
+// skipping empty ones
+func relocateAll(locations []string, relocate func(location string) (string, error)) ([]string, error) {
+	var relocatedLocations []string
+	for _, location := range locations {
+		if location == "" {
+			continue
+		}
+		relocated, relocateError := relocate(location)
+		if relocateError != nil {
+			return nil, relocateError
+		}
+		relocatedLocations = append(relocatedLocations, relocated)
+	}
+	return relocatedLocations, nil
+}
+
+// withLocations returns a copy of the options with the locations of the
+// sources (VIRTUAL-CODEOWNERS.txt, virtual teams, GitHub teams exports,
+// identities and Backstage catalog) replaced by what relocate returns for
+// them
+func withLocations(options cliOptionsType, relocate func(location string) (string, error)) (cliOptionsType, error) {
+	if *options.distributedOwners != "" {
+		return options, fmt.Errorf("can't read the owners files in subdirectories (--distributedOwners) from elsewhere than the working tree")
+	}
+	virtualCodeOwners, relocateError := relocate(*options.virtualCodeOwners)
+	if relocateError != nil {
+		return options, relocateError
+	}
+	// relocate the files the VIRTUAL-CODEOWNERS.txt includes as well, so its
+	// #!include directives find them next to it
+	relocatedContent, readError := readFile(virtualCodeOwners)
+	if readError != nil {
+		return options, readError
+	}
+	_, _, includeError := codeowners.ExpandIncludes(relocatedContent, *options.virtualCodeOwners, func(fileName string) (string, error) {
+		relocated, relocateError := relocate(fileName)
+		if relocateError != nil {
+			return "", relocateError
+		}
+		return readFile(relocated)
+	})
+	if includeError != nil {
+		return options, includeError
+	}
+	teamMap, relocateError := relocateAll(*options.teamMap, relocate)
+	if relocateError != nil {
+		return options, relocateError
+	}
+	gitHubTeams, relocateError := relocateAll(*options.gitHubTeams, relocate)
+	if relocateError != nil {
+		return options, relocateError
+	}
+	identities := ""
+	if *options.identities != "" {
+		identities, relocateError = relocate(*options.identities)
+		if relocateError != nil {
+			return options, relocateError
+		}
+	}
+	backstageCatalog, relocateError := relocateAll(*options.backstageCatalog, relocate)
+	if relocateError != nil {
+		return options, relocateError
+	}
+
+	options.virtualCodeOwners = &virtualCodeOwners
+	options.teamMap = &teamMap
+	options.gitHubTeams = &gitHubTeams
+	options.identities = &identities
+	options.backstageCatalog = &backstageCatalog
+	return options, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sverweij/vcodeowners/internal/codeowners"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+// stringListFlag is a command line flag that can be repeated, e.g.
+// --virtualTeams org-teams.json --virtualTeams .github/virtual-teams.json
+// When it's passed, its values replace the default ones.
+type stringListFlag struct {
+	values []string
+	isSet  bool
+}
+
+func (listFlag *stringListFlag) String() string {
+	return strings.Join(listFlag.values, ", ")
+}
+
+func (listFlag *stringListFlag) Set(value string) error {
+	if !listFlag.isSet {
+		listFlag.values = []string{}
+		listFlag.isSet = true
+	}
+	listFlag.values = append(listFlag.values, value)
+	return nil
+}
+
+func mergeStrategyValid(mergeStrategy string) bool {
+	var validMergeStrategyOptions = map[string]bool{
+		"error":    true,
+		"union":    true,
+		"override": true,
+	}
+	return validMergeStrategyOptions[mergeStrategy]
+}
+
+// getTeamMapFileNames returns the files a team map location refers to: all
+// .json and .ldif files for a directory, the matching files for a glob and otherwise
+// just the location itself.
+func getTeamMapFileNames(location string) ([]string, error) {
+	if fileInfo, statError := os.Stat(location); statError == nil && fileInfo.IsDir() {
+		jsonFileNames, globError := filepath.Glob(filepath.Join(location, "*.json"))
+		if globError != nil {
+			return nil, globError
+		}
+		ldifFileNames, globError := filepath.Glob(filepath.Join(location, "*.ldif"))
+		return append(jsonFileNames, ldifFileNames...), globError
+	}
+	if strings.ContainsAny(location, "*?[") {
+		fileNames, globError := filepath.Glob(location)
+		if globError != nil {
+			return nil, globError
+		}
+		if len(fileNames) == 0 {
+			return nil, fmt.Errorf("no virtual teams files match '%s'", location)
+		}
+		return fileNames, nil
+	}
+	return []string{location}, nil
+}
+
+// getLDIFOptions returns the LDIF options from the command line options,
+// with the group pattern matching case insensitively
+func getLDIFOptions(ldifGroups string, ldifTeamName string, ldifHandle string) (teams.LDIFOptions, error) {
+	groupPattern, groupPatternError := regexp.Compile("(?i)" + ldifGroups)
+	if groupPatternError != nil {
+		return teams.LDIFOptions{}, fmt.Errorf("invalid ldifGroups option '%s'; %w", ldifGroups, groupPatternError)
+	}
+	return teams.LDIFOptions{
+		GroupPattern:    groupPattern,
+		TeamName:        ldifTeamName,
+		HandleAttribute: ldifHandle,
+	}, nil
+}
+
+// teamSourceOptions tell readTeamMaps how to combine team maps and how to
+// read the ones that aren't in the virtual teams format
+type teamSourceOptions struct {
+	mergeStrategy string
+	ldif          teams.LDIFOptions
+	// locations of exports of GitHub teams (see teams.ParseGitHubTeams).
+	// They go before the locations.
+	gitHubTeams []string
+	// sources that don't come from the locations, e.g. the groups in the
+	// Backstage catalog. They go before those from the locations.
+	extraSources []teams.Source
+}
+
+// readTeamMap reads one team map file. Exports of GitHub teams are turned
+// into a team map with the GitHub teams as they are and LDIF exports of a
+// directory (.ldif) into one with the LDIF options; other files should be in
+// the virtual teams format.
+func readTeamMap(fileName string, isGitHubTeams bool, options teamSourceOptions) (teams.Source, codeowners.Anomalies, error) {
+	teamMapBytes, teamMapReadError := os.ReadFile(fileName)
+	if teamMapReadError != nil {
+		return teams.Source{}, nil, teamMapReadError
+	}
+	if isGitHubTeams {
+		teamMap, gitHubTeamsParseError := teams.ParseGitHubTeams(string(teamMapBytes))
+		if gitHubTeamsParseError != nil {
+			return teams.Source{}, nil, fmt.Errorf("%s: %w", fileName, gitHubTeamsParseError)
+		}
+		return teams.Source{Name: fileName, Map: teamMap}, nil, nil
+	}
+	if strings.EqualFold(filepath.Ext(fileName), ".ldif") {
+		teamMap, warnings, ldifParseError := teams.ParseLDIF(string(teamMapBytes), options.ldif)
+		if ldifParseError != nil {
+			return teams.Source{}, nil, fmt.Errorf("%s: %w", fileName, ldifParseError)
+		}
+		return teams.Source{Name: fileName, Map: teamMap}, warnings, nil
+	}
+	teamMap, teamSettings, teamMapParseError := teams.ParseWithSettings(string(teamMapBytes))
+	if teamMapParseError != nil {
+		return teams.Source{}, nil, fmt.Errorf("%s: %w", fileName, teamMapParseError)
+	}
+	return teams.Source{Name: fileName, Map: teamMap, Settings: teamSettings}, nil, nil
+}
+
+// readTeamMapLocations reads the team map files the locations refer to
+func readTeamMapLocations(locations []string, isGitHubTeams bool, options teamSourceOptions) ([]teams.Source, codeowners.Anomalies, error) {
+	var sources []teams.Source
+	var warnings codeowners.Anomalies
+
+	for _, location := range locations {
+		if location == "" {
+			continue
+		}
+		fileNames, fileNamesError := getTeamMapFileNames(location)
+		if fileNamesError != nil {
+			return nil, nil, fileNamesError
+		}
+		slices.Sort(fileNames)
+
+		for _, fileName := range fileNames {
+			source, sourceWarnings, readError := readTeamMap(fileName, isGitHubTeams, options)
+			if readError != nil {
+				return nil, nil, readError
+			}
+			sources = append(sources, source)
+			warnings = append(warnings, sourceWarnings...)
+		}
+	}
+	return sources, warnings, nil
+}
+
+// readTeamMaps reads the team maps from the given locations and merges
+// them into one with the merge strategy. It also returns warnings about
+// what it couldn't read from them.
+func readTeamMaps(locations []string, options teamSourceOptions) (teams.Map, teams.Settings, codeowners.Anomalies, error) {
+	gitHubSources, _, gitHubReadError := readTeamMapLocations(options.gitHubTeams, true, options)
+	if gitHubReadError != nil {
+		return nil, nil, nil, gitHubReadError
+	}
+	locationSources, warnings, readError := readTeamMapLocations(locations, false, options)
+	if readError != nil {
+		return nil, nil, nil, readError
+	}
+	sources := slices.Concat(options.extraSources, gitHubSources, locationSources)
+	teamMap, settings, mergeError := teams.Merge(sources, options.mergeStrategy)
+	return teamMap, settings, warnings, mergeError
+}
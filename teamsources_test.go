@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sverweij/vcodeowners/internal/teams"
+)
+
+func TestStringListFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("replaces the default values when set", func(t *testing.T) {
+		listFlag := stringListFlag{values: []string{"default.json"}}
+		listFlag.Set("one.json")
+		listFlag.Set("two.json")
+
+		assert.Equal([]string{"one.json", "two.json"}, listFlag.values)
+		assert.Equal("one.json, two.json", listFlag.String())
+	})
+}
+
+func TestReadTeamMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "org-teams.json"), []byte(`{"ch/sales": ["jane"], "ch/ux": ["davy"]}`), 0644)
+	os.WriteFile(filepath.Join(directory, "repo-teams.json"), []byte(`{"ch/sales": ["karl"]}`), 0644)
+	os.WriteFile(filepath.Join(directory, "not-a-team-map.txt"), []byte(`not json`), 0644)
+
+	t.Run("reads and merges repeated files", func(t *testing.T) {
+		teamMap, _, _, error := readTeamMaps([]string{
+			filepath.Join(directory, "org-teams.json"),
+			filepath.Join(directory, "repo-teams.json"),
+		}, teamSourceOptions{mergeStrategy: "union"})
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"ch/sales": {"jane", "karl"}, "ch/ux": {"davy"}}, teamMap)
+	})
+
+	t.Run("reads all .json files in a directory", func(t *testing.T) {
+		teamMap, settings, _, error := readTeamMaps([]string{directory}, teamSourceOptions{mergeStrategy: "override"})
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"ch/sales": {"karl"}, "ch/ux": {"davy"}}, teamMap)
+		assert.Equal(
+			map[string][]string{"karl": {filepath.Join(directory, "repo-teams.json")}},
+			settings["ch/sales"].Provenance,
+		)
+	})
+
+	t.Run("reads the files matching a glob", func(t *testing.T) {
+		teamMap, _, _, error := readTeamMaps([]string{filepath.Join(directory, "org-*.json")}, teamSourceOptions{mergeStrategy: "error"})
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"ch/sales": {"jane"}, "ch/ux": {"davy"}}, teamMap)
+	})
+
+	t.Run("skips empty locations", func(t *testing.T) {
+		teamMap, _, _, error := readTeamMaps([]string{""}, teamSourceOptions{mergeStrategy: "error"})
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{}, teamMap)
+	})
+
+	t.Run("reads LDIF exports with the LDIF options, and warns about what it can't map", func(t *testing.T) {
+		ldifFileName := filepath.Join(t.TempDir(), "directory.ldif")
+		os.WriteFile(ldifFileName, []byte(
+			"dn: cn=sales,ou=teams,dc=example,dc=com\n"+
+				"objectClass: groupOfNames\n"+
+				"member: uid=jane,ou=people,dc=example,dc=com\n"+
+				"member: uid=karl,ou=people,dc=example,dc=com\n\n"+
+				"dn: uid=jane,ou=people,dc=example,dc=com\n"+
+				"objectClass: inetOrgPerson\n"+
+				"githubUsername: jane-doe\n",
+		), 0644)
+
+		teamMap, _, warnings, error := readTeamMaps(
+			[]string{filepath.Join(directory, "org-teams.json"), ldifFileName},
+			teamSourceOptions{
+				mergeStrategy: "union",
+				ldif:          teams.LDIFOptions{TeamName: "ch/$1", HandleAttribute: "githubUsername"},
+			},
+		)
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"ch/sales": {"jane", "jane-doe"}, "ch/ux": {"davy"}}, teamMap)
+		assert.Len(warnings, 1)
+	})
+
+	t.Run("reads GitHub teams exports as they are", func(t *testing.T) {
+		gitHubTeamsFileName := filepath.Join(t.TempDir(), "github-teams.json")
+		os.WriteFile(gitHubTeamsFileName, []byte(
+			`{"teams": [{"slug": "sales", "organization": {"login": "cloud-heroes"}}], "members": {"sales": [{"login": "jane-doe"}]}}`,
+		), 0644)
+
+		teamMap, _, _, error := readTeamMaps(
+			[]string{filepath.Join(directory, "org-teams.json")},
+			teamSourceOptions{mergeStrategy: "error", gitHubTeams: []string{gitHubTeamsFileName}},
+		)
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"ch/sales": {"jane"}, "ch/ux": {"davy"}, "cloud-heroes/sales": {"jane-doe"}}, teamMap)
+	})
+
+	t.Run("reads virtual teams files that look like a GitHub teams export as virtual teams", func(t *testing.T) {
+		teamsFileName := filepath.Join(t.TempDir(), "teams.json")
+		os.WriteFile(teamsFileName, []byte(`{"teams": []}`), 0644)
+
+		teamMap, _, _, error := readTeamMaps([]string{teamsFileName}, teamSourceOptions{mergeStrategy: "error"})
+
+		assert.Nil(error)
+		assert.Equal(teams.Map{"teams": {}}, teamMap)
+	})
+
+	t.Run("error: a virtual teams file passed as a GitHub teams export", func(t *testing.T) {
+		_, _, _, error := readTeamMaps(nil, teamSourceOptions{
+			mergeStrategy: "error",
+			gitHubTeams:   []string{filepath.Join(directory, "org-teams.json")},
+		})
+
+		assert.NotNil(error)
+		assert.Equal(
+			filepath.Join(directory, "org-teams.json")+": not a GitHub teams export; that's an object with 'teams' (each with a 'slug') and their 'members'",
+			error.Error(),
+		)
+	})
+
+	t.Run("error: conflicting teams with the error strategy", func(t *testing.T) {
+		_, _, _, error := readTeamMaps([]string{directory}, teamSourceOptions{mergeStrategy: "error"})
+
+		assert.NotNil(error)
+	})
+
+	t.Run("error: glob without matches", func(t *testing.T) {
+		_, _, _, error := readTeamMaps([]string{filepath.Join(directory, "*.yml")}, teamSourceOptions{mergeStrategy: "error"})
+
+		assert.NotNil(error)
+		assert.Equal("no virtual teams files match '"+filepath.Join(directory, "*.yml")+"'", error.Error())
+	})
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sverweij/vcodeowners/internal/checksum"
+)
+
+// formatGenerationOptions returns the options that change what vcodeowners
+// generates from the sources, one 'name=value' per line in a fixed order
+func formatGenerationOptions(options cliOptionsType) string {
+	return strings.Join([]string{
+		"ordering=" + *options.ordering,
+		"seed=" + *options.seed,
+		fmt.Sprintf("largeTeamThreshold=%d", *options.largeTeamThreshold),
+		"normalizeOwners=" + *options.normalizeOwners,
+		"platform=" + *options.platform,
+		"teamMergeStrategy=" + *options.teamMergeStrategy,
+		"ldifGroups=" + *options.ldifGroups,
+		"ldifTeamName=" + *options.ldifTeamName,
+		"ldifHandle=" + *options.ldifHandle,
+	}, "\n")
+}
+
+// getSourcesSum returns the checksum of what vcodeowners generates from:
+// the options that change what it generates, the VIRTUAL-CODEOWNERS.txt, the
+// owners files in subdirectories, the team maps, the identities and the
+// Backstage catalog
+func getSourcesSum(options cliOptionsType) (string, error) {
+	virtualCodeOwners := *options.virtualCodeOwners
+	ownersFileNames := []string{virtualCodeOwners}
+	if *options.distributedOwners != "" {
+		distributedFileNames, fileNamesError := getDistributedOwnersFileNames(*options.distributedOwners, virtualCodeOwners)
+		if fileNamesError != nil {
+			return "", fileNamesError
+		}
+		ownersFileNames = append(ownersFileNames, distributedFileNames...)
+	}
+	contents := []string{formatGenerationOptions(options)}
+	for i, fileName := range ownersFileNames {
+		// the included files are part of the expanded owners files
+		expanded, _, expandError := readVirtualCodeOwners(fileName)
+		if expandError != nil {
+			return "", expandError
+		}
+		// where an owners file in a subdirectory is determines what its
+		// patterns become, so its path counts as well
+		if i > 0 {
+			contents = append(contents, fileName)
+		}
+		contents = append(contents, expanded)
+	}
+
+	var fileNames []string
+	for _, location := range slices.Concat(*options.gitHubTeams, *options.teamMap) {
+		if location == "" {
+			continue
+		}
+		locationFileNames, fileNamesError := getTeamMapFileNames(location)
+		if fileNamesError != nil {
+			return "", fileNamesError
+		}
+		slices.Sort(locationFileNames)
+		fileNames = append(fileNames, locationFileNames...)
+	}
+	if *options.identities != "" {
+		fileNames = append(fileNames, *options.identities)
+	}
+	for _, location := range *options.backstageCatalog {
+		locationFileNames, fileNamesError := getCatalogFileNames(location)
+		if fileNamesError != nil {
+			return "", fileNamesError
+		}
+		slices.Sort(locationFileNames)
+		fileNames = append(fileNames, locationFileNames...)
+	}
+
+	for _, fileName := range fileNames {
+		bytes, readFileError := os.ReadFile(fileName)
+		if readFileError != nil {
+			return "", readFileError
+		}
+		contents = append(contents, string(bytes))
+	}
+	return checksum.Sum(contents...), nil
+}
+
+// describePeriod describes the period the team memberships of a stamp
+// apply in, e.g. "from 2026-03-01 until 2026-04-01"
+func describePeriod(stamp checksum.Stamp) string {
+	var parts []string
+	if stamp.From != "" {
+		parts = append(parts, "from "+stamp.From)
+	}
+	if stamp.Until != "" {
+		parts = append(parts, "until "+stamp.Until)
+	}
+	return strings.Join(parts, " ")
+}
+
+// verifyFile checks the generated file wasn't edited and was generated from
+// the sources with the checksum. It returns an error that tells what to do
+// about it when it wasn't, with the editedHint when it was edited.
+func verifyFile(fileName string, sourcesSum string, editedHint string) error {
+	bytes, readFileError := os.ReadFile(fileName)
+	if readFileError != nil {
+		return readFileError
+	}
+	verifyError := checksum.Verify(string(bytes), sourcesSum, time.Now().Format("2006-01-02"))
+	switch {
+	case errors.Is(verifyError, checksum.ErrNoChecksum):
+		return fmt.Errorf("'%s' %w; it wasn't generated by vcodeowners, or by a version that didn't add one", fileName, verifyError)
+	case errors.Is(verifyError, checksum.ErrEdited):
+		return fmt.Errorf("'%s' %w (edited by hand?); %s", fileName, verifyError, editedHint)
+	case errors.Is(verifyError, checksum.ErrOutdated):
+		return fmt.Errorf("'%s' %w; run 'vcodeowners' to regenerate it", fileName, verifyError)
+	case errors.Is(verifyError, checksum.ErrOtherMemberships):
+		stamp, _, _ := checksum.Split(string(bytes))
+		return fmt.Errorf(
+			"'%s' %w (they apply %s); run 'vcodeowners' to regenerate it",
+			fileName, verifyError, describePeriod(stamp),
+		)
+	}
+	return verifyError
+}
+
+// verifyCli checks the generated CODEOWNERS (and labeler.yml, when there
+// is one) weren't edited after they were generated, and were generated from
+// the current sources - without generating them again
+func verifyCli(arguments []string, output io.Writer) (string, error) {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprint(output, "Usage: vcodeowners verify [options]\n\n")
+		fmt.Fprint(output, "Checks CODEOWNERS (and labeler.yml) weren't edited by hand and are up to date with VIRTUAL-CODEOWNERS.txt and the virtual teams.\n")
+		fmt.Fprint(output, "Pass the options you generate CODEOWNERS with\n\n")
+		flags.PrintDefaults()
+	}
+	options := addOptions(flags)
+
+	if parseError := flags.Parse(arguments); parseError != nil {
+		if errors.Is(parseError, flag.ErrHelp) {
+			return "", nil
+		}
+		return "", parseError
+	}
+
+	sourcesSum, sumError := getSourcesSum(options)
+	if sumError != nil {
+		return "", sumError
+	}
+	if verifyError := verifyFile(*options.codeOwners, sourcesSum, "run 'vcodeowners backport' to keep the edits, or 'vcodeowners' to overwrite them"); verifyError != nil {
+		return "", verifyError
+	}
+	returnMessage := fmt.Sprintf("'%s' is up to date\n", *options.codeOwners)
+
+	if _, statError := os.Stat(*options.labelerLocation); statError == nil {
+		if verifyError := verifyFile(*options.labelerLocation, sourcesSum, "run 'vcodeowners --emitLabeler' to overwrite them"); verifyError != nil {
+			return "", verifyError
+		}
+		returnMessage = returnMessage + fmt.Sprintf("'%s' is up to date\n", *options.labelerLocation)
+	} else if !errors.Is(statError, fs.ErrNotExist) {
+		return "", statError
+	}
+	return returnMessage, nil
+}
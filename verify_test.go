@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCli(t *testing.T) {
+	assert := assert.New(t)
+
+	directory := t.TempDir()
+	vcoFileName := filepath.Join(directory, "VIRTUAL-CODEOWNERS.txt")
+	teamsFileName := filepath.Join(directory, "virtual-teams.json")
+	coFileName := filepath.Join(directory, "CODEOWNERS")
+	labelerFileName := filepath.Join(directory, "labeler.yml")
+	os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+	os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", "karl"]}`), 0644)
+	arguments := []string{
+		"--virtualCodeOwners", vcoFileName,
+		"--virtualTeams", teamsFileName,
+		"--codeOwners", coFileName,
+		"--labelerLocation", labelerFileName,
+	}
+
+	doEmitLabeler := true
+	options := initCliOptions()
+	options.virtualCodeOwners = &vcoFileName
+	options.teamMap = &[]string{teamsFileName}
+	options.codeOwners = &coFileName
+	options.emitLabeler = &doEmitLabeler
+	options.labelerLocation = &labelerFileName
+	_, generateError := cli(options)
+	assert.Nil(generateError)
+
+	t.Run("generated files are up to date", func(t *testing.T) {
+		message, error := verifyCli(arguments, io.Discard)
+
+		assert.Nil(error)
+		assert.Equal("'"+coFileName+"' is up to date\n'"+labelerFileName+"' is up to date\n", message)
+	})
+
+	t.Run("error: generated with other options", func(t *testing.T) {
+		ordering := "source"
+		options.ordering = &ordering
+		defer func() {
+			options.ordering = initCliOptions().ordering
+			cli(options)
+		}()
+		_, generateError := cli(options)
+		assert.Nil(generateError)
+
+		_, error := verifyCli(arguments, io.Discard)
+		assert.NotNil(error)
+		assert.Equal("'"+coFileName+"' was generated from other sources or options than the current ones; run 'vcodeowners' to regenerate it", error.Error())
+
+		message, error := verifyCli(append(slices.Clone(arguments), "--ordering", "source"), io.Discard)
+		assert.Nil(error)
+		assert.Equal("'"+coFileName+"' is up to date\n'"+labelerFileName+"' is up to date\n", message)
+	})
+
+	t.Run("error: sources changed after generating", func(t *testing.T) {
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane"]}`), 0644)
+		defer os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", "karl"]}`), 0644)
+		_, error := verifyCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'"+coFileName+"' was generated from other sources or options than the current ones; run 'vcodeowners' to regenerate it", error.Error())
+	})
+
+	t.Run("error: team memberships changed after generating", func(t *testing.T) {
+		os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", {"name": "karl", "until": "2000-06-30"}]}`), 0644)
+		asOf := "2000-01-01"
+		options.asOf = &asOf
+		defer func() {
+			os.WriteFile(teamsFileName, []byte(`{"ch/sales": ["jane", "karl"]}`), 0644)
+			options.asOf = initCliOptions().asOf
+			cli(options)
+		}()
+		_, generateError := cli(options)
+		assert.Nil(generateError)
+
+		_, error := verifyCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'"+coFileName+"' was generated with the team memberships of another day (they apply until 2000-07-01); run 'vcodeowners' to regenerate it", error.Error())
+	})
+
+	t.Run("error: included file changed after generating", func(t *testing.T) {
+		fragmentFileName := filepath.Join(directory, "docs.txt")
+		os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n#!include docs.txt\n"), 0644)
+		os.WriteFile(fragmentFileName, []byte("docs/ @ch/sales\n"), 0644)
+		defer func() {
+			os.WriteFile(vcoFileName, []byte("libs/sales/ @ch/sales\n"), 0644)
+			cli(options)
+		}()
+		_, generateError := cli(options)
+		assert.Nil(generateError)
+
+		os.WriteFile(fragmentFileName, []byte("docs/ @jane\n"), 0644)
+		_, error := verifyCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'"+coFileName+"' was generated from other sources or options than the current ones; run 'vcodeowners' to regenerate it", error.Error())
+	})
+
+	t.Run("error: labeler.yml edited by hand", func(t *testing.T) {
+		labeler, _ := os.ReadFile(labelerFileName)
+		os.WriteFile(labelerFileName, append(labeler, []byte("# an edit\n")...), 0644)
+		defer os.WriteFile(labelerFileName, labeler, 0644)
+		_, error := verifyCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal(
+			"'"+labelerFileName+"' was edited after it was generated (edited by hand?); run 'vcodeowners --emitLabeler' to overwrite them",
+			error.Error(),
+		)
+	})
+
+	t.Run("error: CODEOWNERS without a checksum", func(t *testing.T) {
+		os.WriteFile(coFileName, []byte("libs/sales/ @jane @karl\n"), 0644)
+		_, error := verifyCli(arguments, io.Discard)
+
+		assert.NotNil(error)
+		assert.Equal("'"+coFileName+"' has no checksum; it wasn't generated by vcodeowners, or by a version that didn't add one", error.Error())
+	})
+}